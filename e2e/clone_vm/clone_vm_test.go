@@ -0,0 +1,264 @@
+package clonevm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	kubev1 "kubevirt.io/api/core/v1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	ifaceName     string = "nic-0"
+	newMacAddress string = "02:00:00:00:e2:e2"
+	domainLabel   string = "kubevirt.io/domain"
+)
+
+func TestVMClone(t *testing.T) {
+	var featName string = "VM Clone"
+	var newSerial string = envconf.RandomName("serial", 12)
+
+	// Populate source VM specification, its disk is a DataVolume cloned from the golden image PVC.
+	// The disk's access and volume modes are resolved in Setup
+	sourceVMSpec := vm.VM{
+		VMName:    sourceVMName,
+		Namespace: namespace,
+		Labels:    map[string]string{domainLabel: sourceVMName},
+		VMSpec: vm.VMSpec{
+			Running:     true,
+			RunStrategy: kubev1.RunStrategyAlways,
+			DataVolumes: []dv.DataVolumeData{
+				{
+					DVSource:         dv.GenerateDataVolumeSourcePVC(dv.GoldenImagesNamespace, *goldenImagePVC),
+					StorageRequests:  "15Gi",
+					StorageClassName: *storageClass,
+				},
+			},
+			VMISpec: vm.VMISpec{
+				Labels: map[string]string{domainLabel: sourceVMName},
+				Networks: []vm.Network{
+					{
+						Name: ifaceName,
+						Type: vm.MasqueradeNetwork,
+					},
+				},
+				VMDomainSpec: vm.VMDomainSpec{
+					RequestsCPU:    "250m",
+					RequestsMemory: "2Gi",
+					Cores:          1,
+					Sockets:        1,
+					Threads:        1,
+				},
+			},
+		},
+	}
+	var sourceVM *kubev1.VirtualMachine
+
+	// Populate the clone specification - the domain label is filtered out of the target's template
+	// so the virt-launcher pods of the source and the target could be told apart
+	vmClone := vm.GenerateVirtualMachineClone(vm.VMClone{
+		CloneName:            cloneName,
+		Namespace:            namespace,
+		SourceVMName:         sourceVMName,
+		TargetVMName:         targetVMName,
+		LabelFilters:         []string{"*", "!" + domainLabel},
+		TemplateLabelFilters: []string{"*", "!" + domainLabel},
+		NewMacAddresses:      map[string]string{ifaceName: newMacAddress},
+		NewSMBiosSerial:      &newSerial,
+	})
+
+	feat := features.New(featName).
+		WithLabel("type", "VM").
+		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			disk, err := dv.ResolveStorage(sourceVMSpec.VMSpec.DataVolumes[0])(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Source VirtualMachine disk on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+			sourceVMSpec.VMSpec.DataVolumes[0] = disk
+
+			sourceVM, err = vm.GenerateVirtualMachine(sourceVMSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Assess("Provision the source VirtualMachine from a DataVolume clone", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := c.Client().Resources(namespace).Create(ctx, sourceVM); err != nil {
+				t.Fatal(err)
+			}
+
+			// The VM becomes Ready only after its DataVolume finished cloning and the guest started
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(sourceVM, vmconditions.VMReady()),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("VirtualMachine, %s, provisioned from a DataVolume clone and Ready after %s", sourceVMName, time.Since(start))
+			return ctx
+		}).
+		Assess("Stop the source VirtualMachine", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			// Patch VM with running false to trigger a VM shutdown, cloning an offline VM does not require online snapshots
+			patchData := []byte(`{"spec": {"running":false}}`)
+			if err := c.Client().Resources(namespace).Patch(ctx, sourceVM, k8s.Patch{PatchType: types.MergePatchType, Data: patchData}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(genVMI(sourceVMName)),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("VirtualMachine, %s, was stopped", sourceVMName)
+			return ctx
+		}).
+		Assess("Clone the source VirtualMachine", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := c.Client().Resources(namespace).Create(ctx, vmClone); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(vmClone, vmconditions.VMCloneSucceeded()),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			elapsed := time.Since(start)
+			t.Logf("VirtualMachineClone, %s, succeeded after %s", cloneName, elapsed)
+			if *maxCloneDuration > 0 && elapsed > *maxCloneDuration {
+				t.Errorf("VirtualMachineClone, %s, took %s, longer than %s", cloneName, elapsed, *maxCloneDuration)
+			}
+			return ctx
+		}).
+		Assess("Target VirtualMachine has a regenerated identity and filtered labels", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var target kubev1.VirtualMachine
+
+			if err := c.Client().Resources(namespace).Get(ctx, targetVMName, namespace, &target); err != nil {
+				t.Fatal(err)
+			}
+
+			for _, iface := range target.Spec.Template.Spec.Domain.Devices.Interfaces {
+				if iface.Name == ifaceName && iface.MacAddress != newMacAddress {
+					t.Errorf("interface %s MAC address not as expected: expected %s, got %s", ifaceName, newMacAddress, iface.MacAddress)
+				}
+			}
+			if fw := target.Spec.Template.Spec.Domain.Firmware; fw == nil || fw.Serial != newSerial {
+				t.Errorf("SMBios serial not as expected: expected %s, got %v", newSerial, fw)
+			}
+			if _, ok := target.ObjectMeta.Labels[domainLabel]; ok {
+				t.Errorf("label %s was expected to be filtered out of VirtualMachine %s", domainLabel, targetVMName)
+			}
+			if _, ok := target.Spec.Template.ObjectMeta.Labels[domainLabel]; ok {
+				t.Errorf("label %s was expected to be filtered out of VirtualMachine %s template", domainLabel, targetVMName)
+			}
+
+			t.Logf("VirtualMachine, %s, was cloned with a new MAC address and SMBios serial", targetVMName)
+			return ctx
+		}).
+		Assess("Target VirtualMachine boots independently of the source", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var gracePeriodSeconds int64 = 30
+
+			// Delete the source so the target can not rely on any of its resources
+			if err := c.Client().Resources(namespace).Delete(ctx, sourceVM, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(sourceVM),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Source VirtualMachine, %s, was deleted", sourceVMName)
+
+			target := &kubev1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      targetVMName,
+					Namespace: namespace,
+				},
+			}
+
+			start := time.Now()
+			// Patch VM with running true to trigger a VM start
+			patchData := []byte(`{"spec": {"running":true}}`)
+			if err := c.Client().Resources(namespace).Patch(ctx, target, k8s.Patch{PatchType: types.MergePatchType, Data: patchData}); err != nil {
+				t.Fatal(err)
+			}
+
+			vmi := genVMI(targetVMName)
+			// Wait for VMI to get created
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(vmi, func(object k8s.Object) bool { return true }),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			// Wait for VMI to become Ready
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(vmi, vmconditions.VMIReady()),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("VirtualMachine, %s, booted without its source after %s", targetVMName, time.Since(start))
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var gracePeriodSeconds int64 = 30
+
+			target := &kubev1.VirtualMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      targetVMName,
+					Namespace: namespace,
+				},
+			}
+
+			objs := []k8s.Object{target, vmClone}
+			// The source might still exist if the test failed before deleting it, or was never generated
+			if sourceVM != nil {
+				objs = append(objs, sourceVM)
+			}
+			for _, obj := range objs {
+				if err := c.Client().Resources(namespace).Delete(ctx, obj, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Logf("failed to delete %s: %v", obj.GetName(), err)
+				}
+			}
+			for _, obj := range objs {
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(obj),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func genVMI(name string) *kubev1.VirtualMachineInstance {
+	return &kubev1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+		},
+	}
+}
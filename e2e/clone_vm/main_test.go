@@ -0,0 +1,82 @@
+package clonevm
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/escalation"
+	"node-e2e/utils/tests"
+
+	clonev1alpha1 "kubevirt.io/api/clone/v1alpha1"
+	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	saName              string = "vm-cloner"
+	namespace           string = "default"
+	vmNamePrefix        string = "node-e2e"
+	pollIntervalSeconds int64  = 10
+	pollTimeoutMinutes  int64  = 5
+	crPath              string = "testdata/vm-cloner.yaml"
+)
+
+var (
+	testsEnvironment env.Environment
+	sourceVMName     string = envconf.RandomName(vmNamePrefix, 13) // Generate a random VM name
+	targetVMName     string = envconf.RandomName(vmNamePrefix, 13)
+	cloneName        string = envconf.RandomName(vmNamePrefix+"-clone", 19)
+	// Storage and golden image of the source VM, e.g. go test ./e2e/clone_vm -args -storage-class=az-a -golden-image-pvc=rhel7-9-az-a
+	// Access and volume modes are taken from the StorageProfile of the storage class
+	storageClass   = flag.String("storage-class", "", "Storage class of the source VM disk, by default the cluster's default one")
+	goldenImagePVC = flag.String("golden-image-pvc", "rhel7-9-az-a", "PVC in "+dv.GoldenImagesNamespace+" the source VM disk is cloned from")
+	// Clone duration SLO, e.g. -max-clone-duration=2m
+	maxCloneDuration = flag.Duration("max-clone-duration", 0, "Fail when the VirtualMachineClone takes longer to succeed, 0 only reports the duration")
+	privAcc          *escalation.ServiceAccount
+	newAcc           *escalation.ServiceAccount
+)
+
+func TestMain(m *testing.M) {
+	e, a, err := tests.StartWithServiceAccountFlags(namespace)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	testsEnvironment = e
+	privAcc = a
+
+	testsEnvironment.Setup(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		a, newCtx, err := tests.SetupWithAccountSwitch(saName, namespace, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			fmt.Printf("Setup failure: %v", err)
+			os.Exit(1)
+		}
+		newAcc = a
+
+		// Add kubevirt.io and clone.kubevirt.io to runtime scheme for later interaction with API groups they provide
+		kubev1.AddToScheme(c.Client().Resources().GetScheme())
+		clonev1alpha1.AddToScheme(c.Client().Resources().GetScheme())
+		// Add cdi.kubevirt.io to runtime scheme to look up the StorageProfile of the storage class
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+
+		return ctx, nil
+	})
+	testsEnvironment.Finish(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		newCtx, err := tests.FinishWithAccountRollback(privAcc, newAcc, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, nil
+	})
+
+	rc := testsEnvironment.Run(m)
+	os.Exit(rc)
+}
@@ -7,6 +7,7 @@ import (
 	"time"
 
 	"node-e2e/utils"
+	vmconditions "node-e2e/utils/conditions"
 	selector "node-e2e/utils/label_selector"
 	"node-e2e/utils/pod"
 
@@ -14,6 +15,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/klient/wait"
 	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
@@ -73,6 +75,35 @@ func TestDaemonSetCreation(t *testing.T) {
 			t.Logf("DaemonSet %s is ready and deployed a pod on each available node", ds.ObjectMeta.GetName())
 			return ctx
 		}).
+		Assess("DaemonSet stays ready and its pods do not restart during the stability window", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if *stabilityWindow == 0 {
+				t.Skip("Stability window disabled")
+			}
+
+			var podList corev1.PodList
+			if err := c.Client().Resources(namespace).List(ctx, &podList, resources.WithLabelSelector(getFirstlabel(testLabels))); err != nil {
+				t.Fatal(err)
+			}
+			objs := []k8s.Object{ds.DeepCopy()}
+			for i := range podList.Items {
+				objs = append(objs, &podList.Items[i])
+			}
+
+			// The container sleeps, so any restart of a pod of the fresh DaemonSet is a crash
+			podStable := vmconditions.All(vmconditions.PodPhaseIs(corev1.PodRunning), vmconditions.PodRestartsAtMost(0))
+			stable := func(obj k8s.Object) (bool, string) {
+				if _, ok := obj.(*appsv1.DaemonSet); ok {
+					return vmconditions.DaemonSetReadyIs()(obj)
+				}
+				return podStable(obj)
+			}
+			if err := vmconditions.ConsistentlyAll(objs, stable, *stabilityWindow, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatalf("DaemonSet %s did not stay ready for %s: %v", ds.ObjectMeta.GetName(), *stabilityWindow, err)
+			}
+
+			t.Logf("DaemonSet %s and its %d pods stayed ready for %s", ds.ObjectMeta.GetName(), len(podList.Items), *stabilityWindow)
+			return ctx
+		}).
 		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
 			// Delete the DaemonSet itself
 			if err := c.Client().Resources(namespace).Delete(ctx, ds); err != nil {
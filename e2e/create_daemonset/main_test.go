@@ -2,9 +2,11 @@ package createdaemonset_test
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"node-e2e/utils/escalation"
 	"node-e2e/utils/tests"
@@ -30,6 +32,7 @@ var (
 	pollTimeoutMinutes  int64 = 2
 	privAcc             *escalation.ServiceAccount
 	newAcc              *escalation.ServiceAccount
+	stabilityWindow     = flag.Duration("stability-window", time.Minute, "How long the DaemonSet must stay ready without any of its pods restarting, 0 skips the check")
 )
 
 func TestMain(m *testing.M) {
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	vmconditions "node-e2e/utils/conditions"
 	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/timeline"
 	"node-e2e/utils/vm"
 	"testing"
 
@@ -26,7 +27,7 @@ import (
 func TestVMCreateInteract(t *testing.T) {
 	var featName string = "VM Creation and Interacion"
 
-	// Populate VM specification
+	// Populate VM specification, the disk's access and volume modes are resolved in Setup
 	vm1 := &vm.VM{
 		VMName:    vmname,
 		Namespace: namespace,
@@ -35,16 +36,12 @@ func TestVMCreateInteract(t *testing.T) {
 			RunStrategy: kubev1.RunStrategyAlways,
 			DataVolumes: []dv.DataVolumeData{
 				{
-					DVSource:         dv.GenerateDataVolumeSourcePVC("openshift-virtualization-os-images", osImagePVC),
-					PVAccessMode:     corev1.ReadWriteMany,
 					StorageRequests:  "15Gi",
-					PVMode:           corev1.PersistentVolumeBlock,
-					StorageClassName: "az-a",
+					StorageClassName: *storageClass,
 				},
 			},
 			VMISpec: vm.VMISpec{
 				NodeName: nil,
-				AZ:       func(s string) *string { return &s }("az-a"),
 				Networks: []vm.Network{
 					{
 						Name: "nic-0",
@@ -62,12 +59,34 @@ func TestVMCreateInteract(t *testing.T) {
 		},
 	}
 
-	// Generate the kubev1.VirtualMachine struct
-	testVM := vm.GenerateVirtualMachine(*vm1)
+	// Boot from the DataSource when one is given, it follows the golden image across clusters and imports
+	if *goldenImage != "" {
+		vm1.VMSpec.DataVolumes[0].SourceRef = dv.GenerateDataVolumeSourceRef(dv.GoldenImagesNamespace, *goldenImage)
+	} else {
+		vm1.VMSpec.DataVolumes[0].DVSource = dv.GenerateDataVolumeSourcePVC(dv.GoldenImagesNamespace, osImagePVC)
+	}
+
+	var testVM *kubev1.VirtualMachine
+
+	// Records the startup milestones of the VM, its VMI and virt-launcher Pod
+	recorder := timeline.NewRecorder()
 
 	feat := features.New(featName).
 		WithLabel("type", "VM").
 		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			disk, err := dv.ResolveStorage(vm1.VMSpec.DataVolumes[0])(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("VirtualMachine disk on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+			vm1.VMSpec.DataVolumes[0] = disk
+
+			// Generate the kubev1.VirtualMachine struct
+			testVM, err = vm.GenerateVirtualMachine(*vm1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			// Initialize the map if it's nil
 			if testVM.ObjectMeta.Labels == nil {
 				testVM.ObjectMeta.Labels = make(map[string]string)
@@ -80,6 +99,32 @@ func TestVMCreateInteract(t *testing.T) {
 				testVM.ObjectMeta.Labels[key] = value
 				testVM.Spec.Template.ObjectMeta.Labels[key] = value
 			}
+
+			// Start recording before the VM is created, so every milestone is timed when it is reached
+			for _, watchFunc := range []func(ctx context.Context, c *envconf.Config) error{
+				recorder.Watch(testVM),
+				recorder.Watch(&kubev1.VirtualMachineInstance{ObjectMeta: metav1.ObjectMeta{Name: vmname, Namespace: namespace}}),
+				recorder.WatchLabeled(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}}, fmt.Sprintf("kubevirt.io/domain=%s", vmname)),
+			} {
+				if err := watchFunc(ctx, c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			return ctx
+		}).
+		Assess("Golden image is available", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if *goldenImage == "" {
+				t.Logf("No golden image DataSource given, booting from PVC %s/%s", dv.GoldenImagesNamespace, osImagePVC)
+				return ctx
+			}
+
+			// Fail fast, a missing or stale golden image would otherwise only show as a DataVolume timeout
+			ds, err := dv.CheckGoldenImage(*goldenImage, dv.GoldenImagesNamespace, *goldenImageMaxAge)(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("Golden image DataSource, %s, is Ready with source %+v", ds.Name, ds.Status.Source)
 			return ctx
 		}).
 		Assess("Create a new VirtualMachine and wait for VirtualMachineInstance and Pod to appear", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
@@ -125,21 +170,37 @@ func TestVMCreateInteract(t *testing.T) {
 			t.Logf("VirtualMachine, %s, VirtualMachineInstance, %s and Pod, %s were created successfully", vmname, vmname, podList.Items[0].GetName())
 			return ctx
 		}).
+		Assess("DataVolume of the VirtualMachine is populated", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+			// DataVolume templates of the VM are named after the VM and numbered from 1
+			dvName := fmt.Sprintf("%s-1", vmname)
+
+			if err := dv.WaitForDataVolume(dvName, namespace, false, time.Minute*time.Duration(pollTimeoutMinutes), time.Duration(pollIntervalSeconds)*time.Second, t.Logf)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("DataVolume, %s, was populated after %s", dvName, time.Since(start))
+			return ctx
+		}).
 		Assess("VirtualMachine, VirtualMachineInstance and VirtLauncher Pod are Ready", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
 
 			resourcesFunc := getTestResources(fmt.Sprintf("kubevirt.io/domain=%s", vmname))
 			vm, vmi, pod := resourcesFunc(ctx, t, c)
 
-			// wait for VM to become Ready
-			if err := wait.For(conditions.New(c.Client().Resources().WithNamespace(namespace)).ResourceMatch(vm, vmconditions.VMReady()),
-				wait.WithTimeout(time.Minute*time.Duration(pollTimeoutMinutes)),
-				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+			// Watch the VM until it is Ready, so the time it became Ready is exact rather than rounded up to the poll interval
+			result, err := vmconditions.WatchUntil(vm, vmconditions.Explain("VM Ready", vmconditions.VMReady()), time.Minute*time.Duration(pollTimeoutMinutes))(ctx, c)
+			for _, transition := range result.Transitions {
+				t.Logf("VirtualMachine, %s, %s at %s (resourceVersion %s)", vmname, transition.Reason, transition.Time.Format(time.RFC3339Nano), transition.ResourceVersion)
+			}
+			if err != nil {
 				t.Fatal(err)
 			}
-			// Wait for VMI to become Ready
-			if err := wait.For(conditions.New(c.Client().Resources().WithNamespace(namespace)).ResourceMatch(vmi, vmconditions.VMIReady()),
-				wait.WithTimeout(time.Minute*time.Duration(pollTimeoutMinutes)),
-				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+			// Wait for VMI to run and become Ready, a timeout reports the phase and Ready condition last seen
+			vmiRunningAndReady := vmconditions.All(
+				vmconditions.VMIPhaseIs(kubev1.Running),
+				vmconditions.ConditionIs(vmconditions.ConditionExpectation{Type: string(kubev1.VirtualMachineInstanceReady), Status: corev1.ConditionTrue}),
+			)
+			if err := vmconditions.Eventually(vmi, vmiRunningAndReady, time.Minute*time.Duration(pollTimeoutMinutes), time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
 				t.Fatal(err)
 			}
 			// Wait for pod to become Ready
@@ -153,6 +214,47 @@ func TestVMCreateInteract(t *testing.T) {
 
 			return ctx
 		}).
+		Assess("VirtualMachine startup milestones are within thresholds", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			t.Logf("Timelines:\n%s", recorder.Report())
+
+			created := timeline.Point{Kind: "VirtualMachine", Name: vmname, Milestone: timeline.Created}
+			stages := []struct {
+				description string
+				to          timeline.Point
+				max         time.Duration
+			}{
+				{"VMI scheduled", timeline.Point{Kind: "VirtualMachineInstance", Name: vmname, Milestone: timeline.Phase(string(kubev1.Scheduled))}, 0},
+				{"virt-launcher running", timeline.Point{Kind: "Pod", Milestone: timeline.Phase(string(corev1.PodRunning))}, 0},
+				{"VMI Ready", timeline.Point{Kind: "VirtualMachineInstance", Name: vmname, Milestone: timeline.Condition(string(kubev1.VirtualMachineInstanceReady), corev1.ConditionTrue)}, *maxReadyLatency},
+			}
+			for _, stage := range stages {
+				if stage.max == 0 {
+					d, err := recorder.Between(created, stage.to)
+					if err != nil {
+						t.Fatal(err)
+					}
+					t.Logf("VirtualMachine, %s, created to %s after %s", vmname, stage.description, d.Round(time.Millisecond))
+					continue
+				}
+				d, err := recorder.Within(created, stage.to, stage.max)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("VirtualMachine, %s, created to %s after %s, within %s", vmname, stage.description, d.Round(time.Millisecond), stage.max)
+			}
+			return ctx
+		}).
+		Assess("Guest OS booted and the guest agent reports the hostname and an IP", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			// A Ready VMI only means virt-launcher is ready, the guest agent connects once the guest OS is up
+			if err := vm.WaitForGuestAgent(vmname, namespace, vmname, "", time.Minute*time.Duration(pollTimeoutMinutes), time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("Guest agent of VirtualMachineInstance, %s, reported its hostname and IP after %s", vmname, time.Since(start))
+			return ctx
+		}).
 		Assess("Restart the VirtualMachine and wait for it to enter Ready state", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
 
 			// Fetch VM, VMI and Pod
@@ -221,6 +323,10 @@ func TestVMCreateInteract(t *testing.T) {
 		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
 			var gracePeriodSeconds int64 = 30
 
+			if err := recorder.Stop(); err != nil {
+				t.Log(err)
+			}
+
 			resourcesFunc := getTestResources(fmt.Sprintf("kubevirt.io/domain=%s", vmname))
 			vm, vmi, pod := resourcesFunc(ctx, t, c)
 
@@ -2,14 +2,17 @@ package createvm
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"testing"
 
+	dv "node-e2e/utils/datavolume"
 	"node-e2e/utils/escalation"
 	"node-e2e/utils/tests"
 
 	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	"sigs.k8s.io/e2e-framework/pkg/env"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 )
@@ -31,8 +34,15 @@ var (
 	labels map[string]string = map[string]string{
 		"kubevirt.io/domain": vmname,
 	}
-	privAcc *escalation.ServiceAccount
-	newAcc  *escalation.ServiceAccount
+	// Golden image parameters, e.g. go test ./e2e/create_vm -args -golden-image=rhel9 -golden-image-max-age=168h
+	goldenImage       = flag.String("golden-image", "", "DataSource in "+dv.GoldenImagesNamespace+" to boot from, by default the "+osImagePVC+" PVC")
+	goldenImageMaxAge = flag.Duration("golden-image-max-age", 0, "Fail when the DataImportCron of the golden image last imported longer ago, 0 skips the check")
+	// Access and volume modes are taken from the StorageProfile of the storage class
+	storageClass = flag.String("storage-class", "", "Storage class of the VM disk, by default the cluster's default one")
+	// Startup latency SLO, e.g. -max-ready-latency=3m
+	maxReadyLatency = flag.Duration("max-ready-latency", 0, "Fail when the VMI takes longer from VM creation to Ready, 0 only reports the latency")
+	privAcc         *escalation.ServiceAccount
+	newAcc          *escalation.ServiceAccount
 )
 
 func TestMain(m *testing.M) {
@@ -55,6 +65,8 @@ func TestMain(m *testing.M) {
 
 		// Add kubevirt.io to runtime scheme for later interaction with API groups it provides
 		kubev1.AddToScheme(c.Client().Resources().GetScheme())
+		// Add cdi.kubevirt.io to runtime scheme to follow the DataVolume the VM boots from and look up its StorageProfile
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
 
 		return ctx, nil
 	})
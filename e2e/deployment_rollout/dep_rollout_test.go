@@ -7,12 +7,16 @@ import (
 	"time"
 
 	"node-e2e/utils"
+	objconditions "node-e2e/utils/conditions"
 	"node-e2e/utils/deployment"
 	selector "node-e2e/utils/label_selector"
 	"node-e2e/utils/pod"
+	"node-e2e/utils/timeline"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 
 	"sigs.k8s.io/e2e-framework/klient/k8s"
@@ -73,6 +77,19 @@ func TestDeploymentRollout(t *testing.T) {
 			if err := c.Client().Resources(namespace).List(ctx, &podList, resources.WithLabelSelector(getFirstlabel(testLabels))); err != nil {
 				t.Fatal(err)
 			}
+			// Record the rollout from before the patch, so its milestones are timed when they are reached
+			recorder := timeline.NewRecorder()
+			defer func() {
+				if err := recorder.Stop(); err != nil {
+					t.Error(err)
+				}
+			}()
+			if err := recorder.Watch(dep)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			if err := recorder.WatchLabeled(&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: namespace}}, getFirstlabel(testLabels))(ctx, c); err != nil {
+				t.Fatal(err)
+			}
 			// Patch Deployment with kubectl.kubernetes.io/restartedAt annotation to trigger a rollout
 			patchData := []byte(fmt.Sprintf(`{"spec": {"template": {"metadata": {"annotations": {"kubectl.kubernetes.io/restartedAt": "%s"}}}}}`, time.Now().Format(time.RFC3339)))
 			if err := c.Client().Resources(namespace).Patch(ctx, dep, k8s.Patch{PatchType: types.MergePatchType, Data: patchData}); err != nil {
@@ -100,8 +117,40 @@ func TestDeploymentRollout(t *testing.T) {
 					t.Fatal(err)
 				}
 			}
+			// The Deployment controller reports the rollout of the patched template as complete,
+			// a condition left over from the previous generation does not count
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(dep, objconditions.ConditionMatch(objconditions.ConditionExpectation{
+				Type:   string(appsv1.DeploymentProgressing),
+				Status: corev1.ConditionTrue,
+				Reason: "NewReplicaSetAvailable",
+				Fresh:  true,
+			})),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
 			t.Logf("Deployment, %s, rolled out successfully", dep.ObjectMeta.GetName())
 
+			// Patched, i.e. the generation of the patched template, to old pods gone to new pods Ready
+			t.Logf("Timelines:\n%s", recorder.Report())
+			patched := timeline.Point{Kind: "Deployment", Name: dep.GetName(), Milestone: timeline.Generation(dep.GetGeneration())}
+			oldPodsGone := timeline.Point{Kind: "Pod", Milestone: timeline.Deleted}
+			newPodsReady := timeline.Point{Kind: "Pod", Milestone: timeline.Condition(string(corev1.PodReady), corev1.ConditionTrue)}
+			d, err := recorder.Between(patched, oldPodsGone)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Deployment, %s, old pods gone %s after the patch", dep.ObjectMeta.GetName(), d.Round(time.Millisecond))
+			if *maxRolloutDuration == 0 {
+				d, err = recorder.Between(patched, newPodsReady)
+			} else {
+				d, err = recorder.Within(patched, newPodsReady, *maxRolloutDuration)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Deployment, %s, new pods Ready %s after the patch", dep.ObjectMeta.GetName(), d.Round(time.Millisecond))
+
 			return ctx
 		}).
 		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
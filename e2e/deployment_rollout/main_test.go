@@ -2,6 +2,7 @@ package deployment_rollout
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"testing"
@@ -31,6 +32,7 @@ var (
 	pollTimeoutMinutes  int64 = 2
 	privAcc             *escalation.ServiceAccount
 	newAcc              *escalation.ServiceAccount
+	maxRolloutDuration  = flag.Duration("max-rollout-duration", 0, "Fail when the new pods take longer from the patch to Ready, 0 only reports the duration")
 )
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,271 @@
+package dvsources
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	dv "node-e2e/utils/datavolume"
+
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	// Size of the generated raw image uploaded when -upload-image-path is not set
+	generatedImageBytes int    = 1024 * 1024
+	uploadDVSize        string = "1Gi"
+)
+
+func TestHTTPImport(t *testing.T) {
+	if *httpImageURL == "" {
+		t.Skip("-http-image-url not set")
+	}
+	dvName := envconf.RandomName(dvNamePrefix+"-http", 18)
+
+	var objs []k8s.Object
+	var opts dv.HTTPSourceOptions
+	if *httpUser != "" {
+		opts.SecretRef = dvName + "-auth"
+		objs = append(objs, dv.GenerateSourceCredentialsSecret(opts.SecretRef, namespace, *httpUser, *httpPassword))
+	}
+	if *httpExtraHeader != "" {
+		opts.ExtraHeaders = []string{*httpExtraHeader}
+	}
+	if *httpSecretHeader != "" {
+		secretName := dvName + "-headers"
+		opts.SecretExtraHeaders = []string{secretName}
+		objs = append(objs, dv.GenerateExtraHeadersSecret(secretName, namespace, map[string]string{"header": *httpSecretHeader}))
+	}
+	if *caFile != "" {
+		opts.CertConfigMap = dvName + "-ca"
+		objs = append(objs, certConfigMap(t, opts.CertConfigMap))
+	}
+
+	testsEnvironment.Test(t, importFeature("DataVolume HTTP Import", dvName, dv.GenerateDataVolumeSourceHTTPWithOptions(*httpImageURL, opts), objs, *httpImageSHA256))
+}
+
+func TestS3Import(t *testing.T) {
+	if *s3ImageURL == "" {
+		t.Skip("-s3-image-url not set")
+	}
+	dvName := envconf.RandomName(dvNamePrefix+"-s3", 16)
+
+	var objs []k8s.Object
+	var secretRef, certConfigMapName string
+	if *s3AccessKeyID != "" {
+		secretRef = dvName + "-auth"
+		objs = append(objs, dv.GenerateSourceCredentialsSecret(secretRef, namespace, *s3AccessKeyID, *s3SecretKey))
+	}
+	if *caFile != "" {
+		certConfigMapName = dvName + "-ca"
+		objs = append(objs, certConfigMap(t, certConfigMapName))
+	}
+
+	testsEnvironment.Test(t, importFeature("DataVolume S3 Import", dvName, dv.GenerateDataVolumeSourceS3(*s3ImageURL, secretRef, certConfigMapName), objs, *s3ImageSHA256))
+}
+
+func TestUpload(t *testing.T) {
+	var featName string = "DataVolume Upload"
+	dvName := envconf.RandomName(dvNamePrefix+"-upload", 20)
+
+	image := make([]byte, generatedImageBytes)
+	if *uploadImagePath != "" {
+		data, err := os.ReadFile(*uploadImagePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		image = data
+	}
+
+	uploadDV := dv.GenerateDataVolume(dvName, namespace, dv.DataVolumeData{
+		DVSource:         dv.GenerateDataVolumeSourceUpload(),
+		UseStorage:       true,
+		StorageRequests:  uploadDVSize,
+		StorageClassName: *storageClass,
+	})
+
+	feat := features.New(featName).
+		WithLabel("type", "DataVolume").
+		Assess("Upload an image to the DataVolume", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if err := c.Client().Resources(namespace).Create(ctx, uploadDV); err != nil {
+				t.Fatal(err)
+			}
+
+			proxyURL := *uploadProxyURL
+			client := &http.Client{
+				Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: *uploadInsecureTLS}},
+			}
+			var standIn *uploadProxyStandIn
+			if proxyURL == "" {
+				standIn = newUploadProxyStandIn()
+				defer standIn.Close()
+				proxyURL = standIn.URL
+				client = standIn.Client()
+			}
+
+			start := time.Now()
+			if err := dv.UploadToDataVolume(dvName, namespace, proxyURL, client, bytes.NewReader(image), int64(len(image)),
+				time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Uploaded %d bytes to %s after %s", len(image), proxyURL, time.Since(start))
+
+			// The stand-in only receives the upload, the DataVolume stays UploadReady
+			if standIn != nil {
+				if received := standIn.Received(); received != len(image) {
+					t.Fatalf("upload stand-in received %d bytes, expected %d", received, len(image))
+				}
+				return ctx
+			}
+
+			if err := dv.WaitForDataVolume(dvName, namespace, false, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second, t.Logf)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("DataVolume, %s, was populated from the upload after %s", dvName, time.Since(start))
+
+			// The uploaded image is raw, so the volume must hold exactly its bytes
+			digest, err := dv.VerifyChecksum(dvName, namespace, fmt.Sprintf("%x", sha256.Sum256(image)), int64(len(image)),
+				time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Content of DataVolume, %s, matches the uploaded image's sha256 %s", dvName, digest)
+			return ctx
+		}).
+		Teardown(deleteObjects(featName, []k8s.Object{uploadDV})).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+// The imported content is verified against expectedSHA256 unless it is empty
+func importFeature(featName, dvName string, source *cdiv1beta1.DataVolumeSource, objs []k8s.Object, expectedSHA256 string) features.Feature {
+	importDV := dv.GenerateDataVolume(dvName, namespace, dv.DataVolumeData{
+		DVSource:         source,
+		UseStorage:       true,
+		StorageRequests:  *imageSize,
+		StorageClassName: *storageClass,
+	})
+	objs = append(objs, importDV)
+
+	return features.New(featName).
+		WithLabel("type", "DataVolume").
+		Assess("Import the image into a DataVolume", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			// Secrets and ConfigMaps go first, the importer fails when a referenced one is missing
+			for _, obj := range objs {
+				if err := c.Client().Resources(namespace).Create(ctx, obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := dv.WaitForDataVolume(dvName, namespace, false, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second, t.Logf)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("DataVolume, %s, was imported after %s", dvName, time.Since(start))
+			return ctx
+		}).
+		Assess("Imported content matches the expected checksum", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if expectedSHA256 == "" {
+				t.Logf("No expected sha256 given, skipping the content verification of DataVolume %s", dvName)
+				return ctx
+			}
+
+			digest, err := dv.VerifyChecksum(dvName, namespace, expectedSHA256, *imageRawBytes, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("Content of DataVolume, %s, matches sha256 %s", dvName, digest)
+			return ctx
+		}).
+		Teardown(deleteObjects(featName, objs)).Feature()
+}
+
+func deleteObjects(featName string, objs []k8s.Object) features.Func {
+	return func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+		var gracePeriodSeconds int64 = 30
+
+		for _, obj := range objs {
+			if err := c.Client().Resources(namespace).Delete(ctx, obj, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+				t.Logf("failed to delete %s: %v", obj.GetName(), err)
+			}
+		}
+		for _, obj := range objs {
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(obj),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+		return ctx
+	}
+}
+
+func certConfigMap(t *testing.T, name string) k8s.Object {
+	caBundle, err := os.ReadFile(*caFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dv.GenerateCertConfigMap(name, namespace, string(caBundle))
+}
+
+// uploadProxyStandIn accepts uploads the way the CDI upload proxy does, so the upload client can be
+// exercised on clusters without an exposed upload proxy. It checks the token is sent and counts the bytes
+type uploadProxyStandIn struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	received int
+}
+
+func newUploadProxyStandIn() *uploadProxyStandIn {
+	s := &uploadProxyStandIn{}
+	s.Server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != dv.UploadProxyPath {
+			http.NotFound(w, r)
+			return
+		}
+		if token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer "); token == "" || token == r.Header.Get("Authorization") {
+			http.Error(w, "missing bearer token", http.StatusUnauthorized)
+			return
+		}
+
+		n, err := io.Copy(io.Discard, r.Body)
+		s.mu.Lock()
+		s.received += int(n)
+		s.mu.Unlock()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	return s
+}
+
+func (s *uploadProxyStandIn) Received() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.received
+}
@@ -0,0 +1,88 @@
+package dvsources
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"node-e2e/utils/escalation"
+	"node-e2e/utils/tests"
+
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	uploadv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/upload/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	saName              string = "dv-sources"
+	namespace           string = "default"
+	dvNamePrefix        string = "node-e2e"
+	pollIntervalSeconds int64  = 10
+	pollTimeoutMinutes  int64  = 15
+	crPath              string = "testdata/dv-sources.yaml"
+)
+
+var (
+	testsEnvironment env.Environment
+	// Source parameters, e.g. go test ./e2e/dv_sources -args -http-image-url=https://images.example.com/rhel.qcow2
+	// Imports whose URL is not set are skipped
+	storageClass      = flag.String("storage-class", "", "Storage class of the DataVolumes, by default the cluster's default one")
+	imageSize         = flag.String("image-size", "15Gi", "Size of the imported DataVolumes")
+	httpImageURL      = flag.String("http-image-url", "", "URL of an image to import over HTTP(S)")
+	httpUser          = flag.String("http-user", "", "Basic auth user of the HTTP server")
+	httpPassword      = flag.String("http-password", "", "Basic auth password of the HTTP server")
+	httpExtraHeader   = flag.String("http-extra-header", "", "Header sent with the HTTP import, in the \"Name: value\" format")
+	httpSecretHeader  = flag.String("http-secret-header", "", "Header sent with the HTTP import through a Secret, in the \"Name: value\" format")
+	httpImageSHA256   = flag.String("http-image-sha256", "", "sha256 of the raw HTTP image, the imported content is verified when set")
+	caFile            = flag.String("ca-file", "", "PEM CA bundle the HTTP and S3 servers' certificates are verified with")
+	s3ImageURL        = flag.String("s3-image-url", "", "URL of an image to import from an S3 compatible bucket")
+	s3AccessKeyID     = flag.String("s3-access-key-id", "", "S3 access key ID")
+	s3SecretKey       = flag.String("s3-secret-key", "", "S3 secret access key")
+	s3ImageSHA256     = flag.String("s3-image-sha256", "", "sha256 of the raw S3 image, the imported content is verified when set")
+	imageRawBytes     = flag.Int64("image-raw-bytes", 0, "Size of the raw imported images, required to verify Block volumes which are larger than the image")
+	uploadProxyURL    = flag.String("upload-proxy-url", "", "URL of the CDI upload proxy, by default a local stand-in receives the upload")
+	uploadImagePath   = flag.String("upload-image-path", "", "Raw image to upload, its content is verified after the upload, by default a small generated one")
+	uploadInsecureTLS = flag.Bool("upload-insecure-tls", false, "Skip verifying the certificate of the upload proxy")
+	privAcc           *escalation.ServiceAccount
+	newAcc            *escalation.ServiceAccount
+)
+
+func TestMain(m *testing.M) {
+	e, a, err := tests.StartWithServiceAccountFlags(namespace)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	testsEnvironment = e
+	privAcc = a
+
+	testsEnvironment.Setup(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		a, newCtx, err := tests.SetupWithAccountSwitch(saName, namespace, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			fmt.Printf("Setup failure: %v", err)
+			os.Exit(1)
+		}
+		newAcc = a
+
+		// Add cdi.kubevirt.io and upload.cdi.kubevirt.io to runtime scheme for later interaction with API groups they provide
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+		uploadv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+
+		return ctx, nil
+	})
+	testsEnvironment.Finish(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		newCtx, err := tests.FinishWithAccountRollback(privAcc, newAcc, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, nil
+	})
+
+	rc := testsEnvironment.Run(m)
+	os.Exit(rc)
+}
@@ -2,9 +2,11 @@ package nodes_test
 
 import (
 	"context"
+	"flag"
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"node-e2e/utils/escalation"
 	"node-e2e/utils/tests"
@@ -19,12 +21,23 @@ const (
 	saName             string = "node-checker"
 	crPath             string = "testdata/node-lister.yaml"
 	pollTimeoutMinutes int64  = 2
+	// Interval readiness is sampled at during the stability window
+	pollIntervalSeconds int64 = 10
 )
 
 var (
 	testsEnvironment env.Environment
 	privAcc          *escalation.ServiceAccount
 	newAcc           *escalation.ServiceAccount
+	stabilityWindow  = flag.Duration("stability-window", 2*time.Minute, "How long all nodes must stay ready without a single failed sample, 0 checks them once")
+	// Node health policy on top of Ready and no pressure, e.g.
+	// -node-conditions=KernelDeadlock=False?,ReadonlyFilesystem=False? -ignore-nodes=node-role.kubernetes.io/infra
+	nodeConditions  = flag.String("node-conditions", "", "Comma separated Type=Status node conditions every node must report, a trailing ? only checks the nodes reporting it, e.g. node-problem-detector ones")
+	ignoreNodes     = flag.String("ignore-nodes", "", "Label selector of the nodes not to check")
+	ignoreTaints    = flag.String("ignore-taints", "", "Comma separated taint keys of the nodes not to check, e.g. node.kubernetes.io/unschedulable")
+	maxHeartbeatAge = flag.Duration("max-heartbeat-age", 0, "Fail on node conditions whose last heartbeat is older, 0 skips the check")
+	// Expected system components per node pool, e.g. -node-baseline=testdata/node-baseline.yaml
+	nodeBaseline = flag.String("node-baseline", "", "YAML file of the system components expected per node pool, by default the nodes of the cluster are only compared with each other")
 )
 
 func TestMain(m *testing.M) {
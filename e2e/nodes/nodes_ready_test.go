@@ -2,14 +2,15 @@ package nodes_test
 
 import (
 	"context"
-	"fmt"
-	"reflect"
+	"strings"
 	"testing"
 	"time"
 
+	vmconditions "node-e2e/utils/conditions"
 	utils "node-e2e/utils/node"
 
 	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
 	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 	"sigs.k8s.io/e2e-framework/pkg/features"
@@ -22,7 +23,7 @@ func TestNodesReadiness(t *testing.T) {
 		WithLabel("type", "Nodes").
 		Assess("All nodes can be listed", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
 
-			if err := c.Client().Resources(namespace).List(ctx, &nodesList, resources.WithTimeout(time.Duration(pollTimeoutMinutes))); err != nil {
+			if err := c.Client().Resources(namespace).List(ctx, &nodesList, resources.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute)); err != nil {
 				t.Fatal(err)
 			}
 			t.Logf("Got %v %s", len(nodesList.Items), resourceType)
@@ -33,10 +34,20 @@ func TestNodesReadiness(t *testing.T) {
 			return ctx
 		}).
 		Assess("All nodes are in ready state", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			policy, err := healthPolicy()
+			if err != nil {
+				t.Fatal(err)
+			}
+
 			var notReady bool
 			for _, node := range nodesList.Items {
-				if condition, ok := utils.IsNodePerfectState(&node); !ok {
-					t.Errorf("Node %v is not perfectly ready: %v", node.Name, condition)
+				if ignored, reason := policy.Ignores(&node); ignored {
+					t.Logf("Node %v is ignored: %s", node.Name, reason)
+					continue
+				}
+				// Report every violation, not only the first one
+				for _, violation := range policy.Check(&node, time.Now()) {
+					t.Errorf("Node %v is not perfectly ready: %v", node.Name, violation)
 					notReady = true
 				}
 			}
@@ -47,61 +58,85 @@ func TestNodesReadiness(t *testing.T) {
 
 			return ctx
 		}).
-		Assess("All nodes system components are latest version", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
-			// True if there is a diff in one of the nodes
-			var diff bool
+		Assess("All nodes stay ready during the stability window", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if *stabilityWindow == 0 {
+				t.Skip("Stability window disabled")
+			}
+			policy, err := healthPolicy()
+			if err != nil {
+				t.Fatal(err)
+			}
 
-			// Take the system info of the first node as a reference
-			refSystemInfo := nodesList.Items[0].Status.NodeInfo
+			// Sample copies, the next steps compare the system info of the listed nodes
+			nodes := make([]k8s.Object, 0, len(nodesList.Items))
+			for i := range nodesList.Items {
+				if ignored, _ := policy.Ignores(&nodesList.Items[i]); !ignored {
+					nodes = append(nodes, nodesList.Items[i].DeepCopy())
+				}
+			}
+			if err := vmconditions.ConsistentlyAll(nodes, vmconditions.NodeHealthy(policy), *stabilityWindow, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatalf("Not all nodes stayed ready for %s: %v", *stabilityWindow, err)
+			}
+			t.Logf("All nodes stayed ready for %s", *stabilityWindow)
 
-			// Compare the system info of each node with the reference
-			for _, node := range nodesList.Items[1:] { // Start from the second node
-				t.Logf("Comparing %s vs %s", nodesList.Items[0].Name, node.Name)
+			return ctx
+		}).
+		Assess("All nodes system components are latest version", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			// Without a baseline every node is unassigned, and only compared with the majority of the nodes
+			baseline := &utils.NodeBaseline{}
+			if *nodeBaseline != "" {
+				b, err := utils.LoadNodeBaseline(*nodeBaseline)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if errs := b.Validate(); len(errs) > 0 {
+					t.Fatal(errs.ToAggregate())
+				}
+				baseline = b
+			}
 
-				systemInfo := node.Status.NodeInfo
-				if diffList, ok := nodeSystemInfoDiff(&refSystemInfo, &systemInfo); ok {
+			// True if there is a diff in one of the nodes
+			var diff bool
+			for _, report := range baseline.Check(nodesList.Items) {
+				t.Logf("Pool %s of %d nodes: %v", report.Pool, len(report.Nodes), report.Majority)
+				for _, violation := range report.Violations {
+					t.Error(violation)
+					diff = true
+				}
+				for _, outlier := range report.Outliers {
+					t.Error(outlier)
 					diff = true
-
-					for _, d := range diffList {
-						t.Log(d)
-					}
 				}
 			}
 			if diff {
-				t.Fatal("Not all nodes have the same SystemInfo")
+				t.Fatal("Not all nodes have the expected SystemInfo")
 			}
-			t.Log("All nodes have the same SystemInfo")
+			t.Log("All nodes have the expected SystemInfo")
 
 			return ctx
 		}).Feature()
 	testsEnvironment.Test(t, feat)
 }
 
-func nodeSystemInfoDiff(refNode *v1.NodeSystemInfo, node *v1.NodeSystemInfo) ([]string, bool) {
-	var diffList []string
-	var diff bool
-
-	// Create a slice of field names to compare
-	fieldNames := []string{
-		"KernelVersion",
-		"OSImage",
-		"ContainerRuntimeVersion",
-		"KubeletVersion",
-		"OperatingSystem",
-		"Architecture",
-	}
-
-	refNodeVal := reflect.ValueOf(refNode).Elem()
-	nodeVal := reflect.ValueOf(node).Elem()
-
-	for _, fieldName := range fieldNames {
-		refField := refNodeVal.FieldByName(fieldName) // returns value in fieldName
-		nodeField := nodeVal.FieldByName(fieldName)   // returns value in fieldName
+// Default node health policy extended by the flags
+func healthPolicy() (utils.NodeHealthPolicy, error) {
+	policy := utils.DefaultNodeHealthPolicy()
 
-		if refField.IsValid() && nodeField.IsValid() && refField.String() != nodeField.String() {
-			diffList = append(diffList, fmt.Sprintf("%s differs: %s vs %s", fieldName, refField.String(), nodeField.String()))
-			diff = true
+	extra, err := utils.ParseConditionRequirements(*nodeConditions)
+	if err != nil {
+		return policy, err
+	}
+	policy.Conditions = append(policy.Conditions, extra...)
+	policy.IgnoreSelector = *ignoreNodes
+	for _, key := range strings.Split(*ignoreTaints, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			policy.IgnoreTaints = append(policy.IgnoreTaints, v1.Taint{Key: key})
 		}
 	}
-	return diffList, diff
+	policy.MaxHeartbeatAge = *maxHeartbeatAge
+
+	if errs := policy.Validate(); len(errs) > 0 {
+		return policy, errs.ToAggregate()
+	}
+	return policy, nil
 }
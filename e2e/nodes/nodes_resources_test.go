@@ -0,0 +1,59 @@
+package nodes_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	utils "node-e2e/utils/node"
+
+	v1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestNodesResources(t *testing.T) {
+	var nodesList v1.NodeList
+	var baseline *utils.NodeBaseline
+
+	feat := features.New("Nodes Capacity, Labels and Taints").
+		WithLabel("type", "Nodes").
+		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if *nodeBaseline == "" {
+				t.Skip("No node baseline given, see -node-baseline")
+			}
+
+			b, err := utils.LoadNodeBaseline(*nodeBaseline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if errs := b.Validate(); len(errs) > 0 {
+				t.Fatal(errs.ToAggregate())
+			}
+			baseline = b
+
+			return ctx
+		}).
+		Assess("All nodes can be listed", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if err := c.Client().Resources(namespace).List(ctx, &nodesList, resources.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute)); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Got %v %s", len(nodesList.Items), resourceType)
+			if len(nodesList.Items) == 0 {
+				t.Fatalf("Expected >0 %s but got %v", resourceType, len(nodesList.Items))
+			}
+
+			return ctx
+		}).
+		Assess("All nodes expose the capacity and carry the labels and taints of their pool", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			mismatches := baseline.CheckResources(nodesList.Items)
+			if len(mismatches) > 0 {
+				t.Fatalf("%d mismatches between the nodes and their pool:\n%s", len(mismatches), utils.FormatDriftTable(mismatches))
+			}
+			t.Log("All nodes expose the capacity and carry the labels and taints of their pool")
+
+			return ctx
+		}).Feature()
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,82 @@
+package vmdensity
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"node-e2e/utils/escalation"
+	"node-e2e/utils/tests"
+
+	kubev1 "kubevirt.io/api/core/v1"
+	poolv1alpha1 "kubevirt.io/api/pool/v1alpha1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	saName              string = "vm-density"
+	namespace           string = "default"
+	vmNamePrefix        string = "node-e2e"
+	osImagePVC          string = "rhel7-9-az-a"
+	pollIntervalSeconds int64  = 10
+	pollTimeoutMinutes  int64  = 5
+	crPath              string = "testdata/vm-density.yaml"
+)
+
+var (
+	testsEnvironment env.Environment
+	groupName        string = envconf.RandomName(vmNamePrefix, 13) // VMs are named <groupName>-<index>
+	// Density parameters, e.g. go test ./e2e/vm_density -args -vm-count=50 -target-node=worker-1
+	vmCount             = flag.Int("vm-count", 10, "Number of VMs to start")
+	concurrency         = flag.Int("concurrency", 5, "Number of VM creations in flight, ignored with -use-pool")
+	targetNode          = flag.String("target-node", "", "Node to start all VMs on, by default the scheduler picks")
+	usePool             = flag.Bool("use-pool", false, "Create the VMs through a VirtualMachinePool instead of one by one")
+	readyTimeoutMinutes = flag.Int64("ready-timeout-minutes", 30, "Time for all VMs to become Ready")
+	// Access and volume modes are taken from the StorageProfile of the storage class
+	storageClass = flag.String("storage-class", "", "Storage class of the VM disks, by default the cluster's default one")
+	privAcc      *escalation.ServiceAccount
+	newAcc       *escalation.ServiceAccount
+)
+
+func TestMain(m *testing.M) {
+	e, a, err := tests.StartWithServiceAccountFlags(namespace)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	testsEnvironment = e
+	privAcc = a
+
+	testsEnvironment.Setup(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		a, newCtx, err := tests.SetupWithAccountSwitch(saName, namespace, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			fmt.Printf("Setup failure: %v", err)
+			os.Exit(1)
+		}
+		newAcc = a
+
+		// Add kubevirt.io and pool.kubevirt.io to runtime scheme for later interaction with API groups they provide
+		kubev1.AddToScheme(c.Client().Resources().GetScheme())
+		poolv1alpha1.AddToScheme(c.Client().Resources().GetScheme())
+		// Add cdi.kubevirt.io to runtime scheme to look up the StorageProfile of the storage class
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+
+		return ctx, nil
+	})
+	testsEnvironment.Finish(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		newCtx, err := tests.FinishWithAccountRollback(privAcc, newAcc, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, nil
+	})
+
+	rc := testsEnvironment.Run(m)
+	os.Exit(rc)
+}
@@ -0,0 +1,167 @@
+package vmdensity
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	kubev1 "kubevirt.io/api/core/v1"
+	poolv1alpha1 "kubevirt.io/api/pool/v1alpha1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestVMDensity(t *testing.T) {
+	var featName string = fmt.Sprintf("VM Density of %d VMs", *vmCount)
+	var vms []*kubev1.VirtualMachine
+	var pool *poolv1alpha1.VirtualMachinePool
+	groupSelector := resources.WithLabelSelector(fmt.Sprintf("%s=%s", vm.DensityGroupLabel, groupName))
+
+	// Populate the description shared by every VM of the group, the disk's access and volume modes are resolved in Setup
+	template := vm.VM{
+		VMName:    groupName,
+		Namespace: namespace,
+		VMSpec: vm.VMSpec{
+			Running:     true,
+			RunStrategy: kubev1.RunStrategyAlways,
+			DataVolumes: []dv.DataVolumeData{
+				{
+					DVSource:         dv.GenerateDataVolumeSourcePVC("openshift-virtualization-os-images", osImagePVC),
+					StorageRequests:  "15Gi",
+					StorageClassName: *storageClass,
+				},
+			},
+			VMISpec: vm.VMISpec{
+				Networks: []vm.Network{
+					{
+						Name: "nic-0",
+						Type: vm.MasqueradeNetwork,
+					},
+				},
+				VMDomainSpec: vm.VMDomainSpec{
+					RequestsCPU:    "250m",
+					RequestsMemory: "2Gi",
+					Cores:          1,
+					Sockets:        1,
+					Threads:        1,
+				},
+			},
+		},
+	}
+	// Pin every VM to the target node to find out how many it can host
+	if *targetNode != "" {
+		template.VMSpec.VMISpec.NodeName = targetNode
+	}
+
+	feat := features.New(featName).
+		WithLabel("type", "VM").
+		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			disk, err := dv.ResolveStorage(template.VMSpec.DataVolumes[0])(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("VirtualMachine disks on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+			template.VMSpec.DataVolumes[0] = disk
+
+			if *usePool {
+				p, err := vm.GenerateVirtualMachinePool(template, int32(*vmCount))
+				if err != nil {
+					t.Fatal(err)
+				}
+				pool = p
+			} else {
+				generated, err := vm.GenerateVirtualMachines(template, *vmCount)
+				if err != nil {
+					t.Fatal(err)
+				}
+				vms = generated
+			}
+			return ctx
+		}).
+		Assess("Create the VirtualMachines", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if pool != nil {
+				if err := c.Client().Resources(namespace).Create(ctx, pool); err != nil {
+					t.Fatal(err)
+				}
+			} else if err := vm.CreateVirtualMachines(vms, *concurrency)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("%d VirtualMachines of group %s were created after %s", *vmCount, groupName, time.Since(start))
+			return ctx
+		}).
+		Assess("All VirtualMachines become Ready", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceListMatchN(&kubev1.VirtualMachineList{}, *vmCount, vmconditions.VMReady(), groupSelector),
+				wait.WithTimeout(time.Duration(*readyTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("All %d VirtualMachines of group %s are Ready after %s", *vmCount, groupName, time.Since(start))
+			return ctx
+		}).
+		Assess("Report startup latencies", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			latencies, err := vm.GetStartupLatencies(groupName, namespace)(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, l := range latencies {
+				t.Logf("VirtualMachine %s on node %s: scheduled after %s, running after %s, ready after %s", l.VMName, l.NodeName, l.Scheduled, l.Running, l.Ready)
+				if *targetNode != "" && l.NodeName != *targetNode {
+					t.Errorf("VirtualMachine %s node not as expected: expected %s, got %s", l.VMName, *targetNode, l.NodeName)
+				}
+			}
+
+			t.Logf("Creation to scheduled: %s", vm.SummarizeLatencies(latencies, func(l vm.StartupLatency) time.Duration { return l.Scheduled }))
+			t.Logf("Creation to running: %s", vm.SummarizeLatencies(latencies, func(l vm.StartupLatency) time.Duration { return l.Running }))
+			t.Logf("Creation to ready: %s", vm.SummarizeLatencies(latencies, func(l vm.StartupLatency) time.Duration { return l.Ready }))
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var gracePeriodSeconds int64 = 30
+			var objList []k8s.Object
+
+			// Deleting the pool deletes its VMs
+			if pool != nil {
+				objList = append(objList, pool)
+			}
+			for _, obj := range vms {
+				objList = append(objList, obj)
+			}
+			for _, obj := range objList {
+				if err := c.Client().Resources(namespace).Delete(ctx, obj, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Logf("failed to delete %s: %v", obj.GetName(), err)
+				}
+			}
+
+			vmList := &kubev1.VirtualMachineList{}
+			if err := c.Client().Resources(namespace).List(ctx, vmList, groupSelector); err != nil {
+				t.Fatal(err)
+			}
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourcesDeleted(vmList),
+				wait.WithTimeout(time.Duration(*readyTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,75 @@
+package vmhotplug
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"node-e2e/utils/escalation"
+	"node-e2e/utils/tests"
+
+	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	saName              string = "vm-hotplug"
+	namespace           string = "default"
+	vmNamePrefix        string = "node-e2e"
+	osImagePVC          string = "rhel7-9-az-a"
+	pollIntervalSeconds int64  = 10
+	pollTimeoutMinutes  int64  = 5
+	crPath              string = "testdata/vm-hotplug.yaml"
+)
+
+var (
+	testsEnvironment env.Environment
+	vmname           string = envconf.RandomName(vmNamePrefix, 13) // Generate a random VM name
+	hotplugDVName    string = envconf.RandomName(vmNamePrefix+"-hotplug", 21)
+	// Access and volume modes are taken from the StorageProfile of the storage class
+	storageClass = flag.String("storage-class", "", "Storage class of the VM disk and the hotplugged DataVolume, by default the cluster's default one")
+	privAcc      *escalation.ServiceAccount
+	newAcc       *escalation.ServiceAccount
+)
+
+func TestMain(m *testing.M) {
+	e, a, err := tests.StartWithServiceAccountFlags(namespace)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	testsEnvironment = e
+	privAcc = a
+
+	testsEnvironment.Setup(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		a, newCtx, err := tests.SetupWithAccountSwitch(saName, namespace, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			fmt.Printf("Setup failure: %v", err)
+			os.Exit(1)
+		}
+		newAcc = a
+
+		// Add kubevirt.io to runtime scheme for later interaction with the API group it provides
+		kubev1.AddToScheme(c.Client().Resources().GetScheme())
+		// Add cdi.kubevirt.io to runtime scheme, the hotplugged disk is a standalone DataVolume
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+
+		return ctx, nil
+	})
+	testsEnvironment.Finish(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		newCtx, err := tests.FinishWithAccountRollback(privAcc, newAcc, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, nil
+	})
+
+	rc := testsEnvironment.Run(m)
+	os.Exit(rc)
+}
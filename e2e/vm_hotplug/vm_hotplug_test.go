@@ -0,0 +1,210 @@
+package vmhotplug
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	kubev1 "kubevirt.io/api/core/v1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	guestUser      string = "cloud-user"
+	guestPassword  string = "node-e2e-hotplug"
+	hotplugVolName string = "hotplug-disk"
+	// The serial exposes the disk as /dev/disk/by-id/*<serial> in the guest
+	hotplugSerial  string = "hotplug01"
+	consoleTimeout        = 2 * time.Minute
+)
+
+func TestVMVolumeHotplug(t *testing.T) {
+	var featName string = "VM Volume Hotplug"
+	// Lists the disk by its serial, exits non zero when the disk is not attached
+	findDiskCmd := fmt.Sprintf("ls /dev/disk/by-id/ | grep %s", hotplugSerial)
+
+	// Populate VM specification, the disk's access and volume modes are resolved in Setup
+	vmSpec := vm.VM{
+		VMName:    vmname,
+		Namespace: namespace,
+		Labels:    map[string]string{"kubevirt.io/domain": vmname},
+		VMSpec: vm.VMSpec{
+			Running:     true,
+			RunStrategy: kubev1.RunStrategyAlways,
+			DataVolumes: []dv.DataVolumeData{
+				{
+					DVSource:         dv.GenerateDataVolumeSourcePVC("openshift-virtualization-os-images", osImagePVC),
+					StorageRequests:  "15Gi",
+					StorageClassName: *storageClass,
+				},
+			},
+			VMISpec: vm.VMISpec{
+				Labels:            map[string]string{"kubevirt.io/domain": vmname},
+				CloudInitPassword: guestPassword,
+				Networks: []vm.Network{
+					{
+						Name: "nic-0",
+						Type: vm.MasqueradeNetwork,
+					},
+				},
+				VMDomainSpec: vm.VMDomainSpec{
+					RequestsCPU:    "250m",
+					RequestsMemory: "2Gi",
+					Cores:          1,
+					Sockets:        1,
+					Threads:        1,
+				},
+			},
+		},
+	}
+	var testVM *kubev1.VirtualMachine
+
+	// Blank disk to hotplug. Access and volume modes are left for CDI to take from the StorageProfile,
+	// and a storage class binding on first consumer provisions it in the zone of the VM
+	hotplugDV := dv.GenerateDataVolume(hotplugDVName, namespace, dv.DataVolumeData{
+		DVSource:         dv.GenerateDataVolumeBlank(),
+		UseStorage:       true,
+		StorageRequests:  "1Gi",
+		StorageClassName: *storageClass,
+	})
+
+	hotplugVol := vm.Volume{
+		Name: hotplugVolName,
+		Type: vm.DataVolume,
+		Disk: vm.DiskOptions{
+			Bus:    kubev1.DiskBusSCSI,
+			Serial: hotplugSerial,
+		},
+		Source: vm.VolumeSourceOptions{
+			Name: hotplugDVName,
+		},
+	}
+
+	feat := features.New(featName).
+		WithLabel("type", "VM").
+		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			disk, err := dv.ResolveStorage(vmSpec.VMSpec.DataVolumes[0])(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("VirtualMachine disk on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+			vmSpec.VMSpec.DataVolumes[0] = disk
+
+			testVM, err = vm.GenerateVirtualMachine(vmSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return ctx
+		}).
+		Assess("Create the VirtualMachine and a blank DataVolume and wait for the guest to boot", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, obj := range []k8s.Object{testVM, hotplugDV} {
+				if err := c.Client().Resources(namespace).Create(ctx, obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			// The storage class may bind the blank disk only once the hotplug attachment pod consumes it
+			if err := dv.WaitForDataVolume(hotplugDVName, namespace, true, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second, t.Logf)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(testVM, vmconditions.VMReady()),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+			// The console login needs the guest OS up, not only virt-launcher
+			if err := vm.WaitForGuestAgent(vmname, namespace, "", "", time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("VirtualMachine, %s, booted and DataVolume, %s, is ready to be hotplugged", vmname, hotplugDVName)
+			return ctx
+		}).
+		Assess("Hotplug the DataVolume and find the disk in the guest", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := vm.HotplugVolume(vmname, namespace, hotplugVol, true, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Volume, %s, reported Ready after %s", hotplugVolName, time.Since(start))
+
+			out, rc := runInGuest(ctx, t, c, findDiskCmd)
+			if rc != 0 {
+				t.Fatalf("hotplugged disk with serial %s not found in the guest: rc %d, output %q", hotplugSerial, rc, out)
+			}
+
+			t.Logf("Hotplugged disk, %s, found in the guest: %s", hotplugSerial, out)
+			return ctx
+		}).
+		Assess("Unplug the DataVolume and make sure the disk is gone from the guest", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := vm.UnplugVolume(vmname, namespace, hotplugVolName, true, time.Duration(pollTimeoutMinutes)*time.Minute, time.Duration(pollIntervalSeconds)*time.Second)(ctx, c); err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("Volume, %s, removed after %s", hotplugVolName, time.Since(start))
+
+			out, rc := runInGuest(ctx, t, c, findDiskCmd)
+			if rc == 0 {
+				t.Fatalf("unplugged disk with serial %s still found in the guest: %q", hotplugSerial, out)
+			}
+
+			t.Logf("Unplugged disk, %s, is gone from the guest", hotplugSerial)
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var gracePeriodSeconds int64 = 30
+			objs := []k8s.Object{hotplugDV}
+			if testVM != nil {
+				objs = append(objs, testVM)
+			}
+
+			for _, obj := range objs {
+				if err := c.Client().Resources(namespace).Delete(ctx, obj, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Logf("failed to delete %s: %v", obj.GetName(), err)
+				}
+			}
+			for _, obj := range objs {
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(obj),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+// Runs cmd through the serial console, a new console connection is opened for every command
+// as only one connection per VMI is allowed
+func runInGuest(ctx context.Context, t *testing.T, c *envconf.Config, cmd string) (string, int) {
+	console, err := vm.OpenConsole(vmname, namespace)(ctx, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer console.Close()
+
+	if err := console.Login(guestUser, guestPassword, consoleTimeout); err != nil {
+		t.Fatal(err)
+	}
+	out, rc, err := console.RunCommand(cmd, consoleTimeout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out, rc
+}
@@ -0,0 +1,78 @@
+package vminstancetype
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"testing"
+
+	"node-e2e/utils/escalation"
+	"node-e2e/utils/tests"
+
+	kubev1 "kubevirt.io/api/core/v1"
+	instancetypev1beta1 "kubevirt.io/api/instancetype/v1beta1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	saName              string = "vm-instancetype"
+	namespace           string = "default"
+	vmNamePrefix        string = "node-e2e"
+	osImagePVC          string = "rhel7-9-az-a"
+	pollIntervalSeconds int64  = 10
+	pollTimeoutMinutes  int64  = 5
+	crPath              string = "testdata/vm-instancetype.yaml"
+)
+
+var (
+	testsEnvironment env.Environment
+	vmName           string = envconf.RandomName(vmNamePrefix, 13) // Generate a random VM name
+	instancetypeName string = envconf.RandomName(vmNamePrefix+"-it", 16)
+	preferenceName   string = envconf.RandomName(vmNamePrefix+"-pref", 18)
+	// Access and volume modes are taken from the StorageProfile of the storage class
+	storageClass = flag.String("storage-class", "", "Storage class of the VM disk, by default the cluster's default one")
+	privAcc      *escalation.ServiceAccount
+	newAcc       *escalation.ServiceAccount
+)
+
+func TestMain(m *testing.M) {
+	e, a, err := tests.StartWithServiceAccountFlags(namespace)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	testsEnvironment = e
+	privAcc = a
+
+	testsEnvironment.Setup(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		a, newCtx, err := tests.SetupWithAccountSwitch(saName, namespace, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			fmt.Printf("Setup failure: %v", err)
+			os.Exit(1)
+		}
+		newAcc = a
+
+		// Add kubevirt.io and instancetype.kubevirt.io to runtime scheme for later interaction with API groups they provide
+		kubev1.AddToScheme(c.Client().Resources().GetScheme())
+		instancetypev1beta1.AddToScheme(c.Client().Resources().GetScheme())
+		// Add cdi.kubevirt.io to runtime scheme to look up the StorageProfile of the storage class
+		cdiv1beta1.AddToScheme(c.Client().Resources().GetScheme())
+
+		return ctx, nil
+	})
+	testsEnvironment.Finish(func(ctx context.Context, c *envconf.Config) (context.Context, error) {
+		newCtx, err := tests.FinishWithAccountRollback(privAcc, newAcc, crPath)(ctx, c)
+		ctx = newCtx
+		if err != nil {
+			return ctx, err
+		}
+		return ctx, nil
+	})
+
+	rc := testsEnvironment.Run(m)
+	os.Exit(rc)
+}
@@ -0,0 +1,166 @@
+package vminstancetype
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	kubev1 "kubevirt.io/api/core/v1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	guestCPUs   uint32 = 2
+	guestMemory string = "2Gi"
+)
+
+func TestVMInstancetype(t *testing.T) {
+	var featName string = "VM Instancetype"
+
+	instancetype := vm.GenerateVirtualMachineInstancetype(instancetypeName, namespace, vm.InstancetypeSpec{
+		CPUs:   guestCPUs,
+		Memory: guestMemory,
+	})
+	// Spreading the vCPUs over cores makes the preference observable in the VMI's CPU topology
+	preference := vm.GenerateVirtualMachinePreference(preferenceName, namespace, vm.PreferenceSpec{
+		CPUTopology: "preferCores",
+		DiskBus:     kubev1.DiskBusVirtio,
+	})
+
+	// Populate VM specification, sizing is taken from the instancetype rather than the domain.
+	// The disk's access and volume modes are resolved in Setup
+	vmSpec := vm.VM{
+		VMName:    vmName,
+		Namespace: namespace,
+		VMSpec: vm.VMSpec{
+			Running:     true,
+			RunStrategy: kubev1.RunStrategyAlways,
+			Instancetype: &vm.InstancetypeRef{
+				Name: instancetypeName,
+				Kind: vm.InstancetypeKind,
+			},
+			Preference: &vm.PreferenceRef{
+				Name: preferenceName,
+				Kind: vm.PreferenceKind,
+			},
+			DataVolumes: []dv.DataVolumeData{
+				{
+					DVSource:         dv.GenerateDataVolumeSourcePVC("openshift-virtualization-os-images", osImagePVC),
+					StorageRequests:  "15Gi",
+					StorageClassName: *storageClass,
+				},
+			},
+			VMISpec: vm.VMISpec{
+				Networks: []vm.Network{
+					{
+						Name: "nic-0",
+						Type: vm.MasqueradeNetwork,
+					},
+				},
+			},
+		},
+	}
+	var vmObj *kubev1.VirtualMachine
+
+	feat := features.New(featName).
+		WithLabel("type", "VM").
+		Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			disk, err := dv.ResolveStorage(vmSpec.VMSpec.DataVolumes[0])(ctx, c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Logf("VirtualMachine disk on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+			vmSpec.VMSpec.DataVolumes[0] = disk
+
+			vmObj, err = vm.GenerateVirtualMachine(vmSpec)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// The instancetype and the preference must exist before the VM referencing them is created
+			for _, obj := range []k8s.Object{instancetype, preference} {
+				if err := c.Client().Resources(namespace).Create(ctx, obj); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Logf("VirtualMachineInstancetype, %s, and VirtualMachinePreference, %s, were created", instancetypeName, preferenceName)
+			return ctx
+		}).
+		Assess("VirtualMachine referencing an instancetype becomes Ready", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			start := time.Now()
+
+			if err := c.Client().Resources(namespace).Create(ctx, vmObj); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(vmObj, vmconditions.VMReady()),
+				wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+				wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+				t.Fatal(err)
+			}
+
+			t.Logf("VirtualMachine, %s, is Ready after %s", vmName, time.Since(start))
+			return ctx
+		}).
+		Assess("VirtualMachineInstance is sized by the instancetype and shaped by the preference", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var vmi kubev1.VirtualMachineInstance
+
+			if err := c.Client().Resources(namespace).Get(ctx, vmName, namespace, &vmi); err != nil {
+				t.Fatal(err)
+			}
+
+			cpu := vmi.Spec.Domain.CPU
+			if cpu == nil || cpu.Cores != guestCPUs || cpu.Sockets != 1 {
+				t.Errorf("CPU topology not as expected: expected %d cores on 1 socket, got %+v", guestCPUs, cpu)
+			}
+			expectedMemory := resource.MustParse(guestMemory)
+			if mem := vmi.Spec.Domain.Memory; mem == nil || mem.Guest == nil || !mem.Guest.Equal(expectedMemory) {
+				t.Errorf("guest memory not as expected: expected %s, got %+v", guestMemory, mem)
+			}
+			for _, disk := range vmi.Spec.Domain.Devices.Disks {
+				if disk.Disk != nil && disk.Disk.Bus != kubev1.DiskBusVirtio {
+					t.Errorf("disk %s bus not as expected: expected %s, got %s", disk.Name, kubev1.DiskBusVirtio, disk.Disk.Bus)
+				}
+			}
+
+			t.Logf("VirtualMachineInstance, %s, has %d cores and %s of guest memory", vmName, guestCPUs, guestMemory)
+			return ctx
+		}).
+		Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			var gracePeriodSeconds int64 = 30
+
+			// The VM goes first, the instancetype and preference are kept in its ControllerRevisions anyway
+			objs := []k8s.Object{instancetype, preference}
+			if vmObj != nil {
+				objs = append([]k8s.Object{vmObj}, objs...)
+			}
+			for _, obj := range objs {
+				if err := c.Client().Resources(namespace).Delete(ctx, obj, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Logf("failed to delete %s: %v", obj.GetName(), err)
+				}
+			}
+			for _, obj := range objs {
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(obj),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
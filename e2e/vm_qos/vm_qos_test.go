@@ -0,0 +1,170 @@
+package vmqos
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubev1 "kubevirt.io/api/core/v1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestVMQOSClass(t *testing.T) {
+	var feats []features.Feature
+
+	tests := []struct {
+		vmName string
+		domain vm.VMDomainSpec
+	}{
+		{
+			// Limits equal requests for both CPU and memory
+			vmName: guaranteedVMName,
+			domain: vm.VMDomainSpec{
+				RequestsCPU:    "1",
+				RequestsMemory: "2Gi",
+				LimitPolicy:    vm.LimitPolicyGuaranteed,
+				Cores:          1,
+				Sockets:        1,
+				Threads:        1,
+			},
+		},
+		{
+			// The guest is given more memory than the pod requests and CPU may burst up to twice the requests
+			vmName: burstableVMName,
+			domain: vm.VMDomainSpec{
+				RequestsCPU:             "250m",
+				GuestMemory:             "2Gi",
+				MemoryOvercommitPercent: 150,
+				LimitPolicy:             vm.LimitPolicyRatio,
+				CPULimitsRatio:          2,
+				Cores:                   1,
+				Sockets:                 1,
+				Threads:                 1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		expectedQOSClass := vm.ExpectedQOSClass(tt.domain)
+		featName := "VM " + string(expectedQOSClass) + " QoS class"
+
+		// The disk's access and volume modes are resolved in Setup
+		vmSpec := vm.VM{
+			VMName:    tt.vmName,
+			Namespace: namespace,
+			VMSpec: vm.VMSpec{
+				Running:     true,
+				RunStrategy: kubev1.RunStrategyAlways,
+				DataVolumes: []dv.DataVolumeData{
+					{
+						DVSource:         dv.GenerateDataVolumeSourcePVC("openshift-virtualization-os-images", osImagePVC),
+						StorageRequests:  "15Gi",
+						StorageClassName: *storageClass,
+					},
+				},
+				VMISpec: vm.VMISpec{
+					Networks: []vm.Network{
+						{
+							Name: "nic-0",
+							Type: vm.MasqueradeNetwork,
+						},
+					},
+					VMDomainSpec: tt.domain,
+				},
+			},
+		}
+		var testVM *kubev1.VirtualMachine
+
+		feat := features.New(featName).
+			WithLabel("type", "VM").
+			Setup(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				disk, err := dv.ResolveStorage(vmSpec.VMSpec.DataVolumes[0])(ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("VirtualMachine disk on storage class %s with access modes %v and volume mode %s", disk.StorageClassName, disk.PVAccessModes, disk.PVMode)
+				vmSpec.VMSpec.DataVolumes[0] = disk
+
+				testVM, err = vm.GenerateVirtualMachine(vmSpec)
+				if err != nil {
+					t.Fatal(err)
+				}
+				return ctx
+			}).
+			Assess("VirtualMachine is scheduled and becomes Ready", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				start := time.Now()
+
+				if err := c.Client().Resources(namespace).Create(ctx, testVM); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(testVM, vmconditions.VMReady()),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+
+				t.Logf("VirtualMachine, %s, is Ready after %s", tt.vmName, time.Since(start))
+				return ctx
+			}).
+			Assess("VirtualMachineInstance and virt-launcher Pod have the expected QoS class", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				vmi := &kubev1.VirtualMachineInstance{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      tt.vmName,
+						Namespace: namespace,
+					},
+				}
+
+				// The QoS class is reported on the VMI shortly after its pod was scheduled
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(vmi, vmconditions.VMIQOSClassMatch(expectedQOSClass)),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Errorf("VirtualMachineInstance %s QoS class not as expected: expected %s, got %v", tt.vmName, expectedQOSClass, vmi.Status.QOSClass)
+				}
+
+				qosClass, err := vm.GetVirtLauncherQOSClass(tt.vmName, namespace)(ctx, c)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if qosClass != expectedQOSClass {
+					t.Errorf("virt-launcher Pod of %s QoS class not as expected: expected %s, got %s", tt.vmName, expectedQOSClass, qosClass)
+				}
+
+				t.Logf("virt-launcher Pod of VirtualMachine, %s, has the %s QoS class", tt.vmName, qosClass)
+				return ctx
+			}).
+			Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				var gracePeriodSeconds int64 = 30
+
+				// Setup failed before the VM was generated
+				if testVM == nil {
+					return ctx
+				}
+				if err := c.Client().Resources(namespace).Delete(ctx, testVM, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(testVM),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+				return ctx
+			}).Feature()
+
+		feats = append(feats, feat)
+	}
+
+	testsEnvironment.Test(t, feats...)
+}
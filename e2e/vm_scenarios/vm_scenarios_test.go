@@ -0,0 +1,106 @@
+package vmscenarios
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+	dv "node-e2e/utils/datavolume"
+	"node-e2e/utils/vm"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubev1 "kubevirt.io/api/core/v1"
+
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+// Each YAML file under testdata/scenarios describes a vm.VM which is generated and booted. Adding a
+// scenario requires no Go changes, only its golden manifest under utils/vm/testdata/golden, which
+// UPDATE_GOLDEN=true go test ./utils/vm writes.
+func TestVMScenarios(t *testing.T) {
+	var feats []features.Feature
+
+	scenarios, err := filepath.Glob(filepath.Join(scenariosDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scenario := range scenarios {
+		scenarioName := strings.TrimSuffix(filepath.Base(scenario), filepath.Ext(scenario))
+		featName := "VM scenario " + scenarioName
+		vmName := envconf.RandomName(vmNamePrefix, 13)
+
+		feat := features.New(featName).
+			WithLabel("type", "VM").
+			Assess("VirtualMachine becomes Ready", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				v, err := vm.LoadVM(scenario, map[string]interface{}{
+					"vmName":       vmName,
+					"namespace":    namespace,
+					"osImagePVC":   osImagePVC,
+					"storageClass": *storageClass,
+					"password":     envconf.RandomName("", 12),
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				// Scenarios state the access and volume modes they need, if any, the rest is taken from the StorageProfile
+				for i := range v.VMSpec.DataVolumes {
+					disk, err := dv.ResolveStorage(v.VMSpec.DataVolumes[i])(ctx, c)
+					if err != nil {
+						t.Fatal(err)
+					}
+					v.VMSpec.DataVolumes[i] = disk
+				}
+				testVM, err := vm.GenerateVirtualMachine(*v)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				start := time.Now()
+				if err := c.Client().Resources(namespace).Create(ctx, testVM); err != nil {
+					t.Fatal(err)
+				}
+
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceMatch(testVM, vmconditions.VMReady()),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+
+				t.Logf("VirtualMachine, %s, of scenario %s is Ready after %s", vmName, scenarioName, time.Since(start))
+				return ctx
+			}).
+			Teardown(func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+				var gracePeriodSeconds int64 = 30
+
+				testVM := &kubev1.VirtualMachine{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      vmName,
+						Namespace: namespace,
+					},
+				}
+				if err := c.Client().Resources(namespace).Delete(ctx, testVM, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second)); err != nil {
+					t.Logf("failed to delete %s: %v", vmName, err)
+				}
+				if err := wait.For(conditions.New(c.Client().Resources(namespace)).ResourceDeleted(testVM),
+					wait.WithTimeout(time.Duration(pollTimeoutMinutes)*time.Minute),
+					wait.WithInterval(time.Duration(pollIntervalSeconds)*time.Second)); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("All resources have been deleted. %s test has finished successfully!", featName)
+
+				return ctx
+			}).Feature()
+
+		feats = append(feats, feat)
+	}
+
+	testsEnvironment.Test(t, feats...)
+}
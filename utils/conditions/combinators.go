@@ -0,0 +1,281 @@
+package conditions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	nodeutils "node-e2e/utils/node"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	kubev1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+// Predicate reports whether the object matches together with what was observed, so a wait which times out
+// can tell what the object looked like instead of only that it did not match
+type Predicate func(obj k8s.Object) (bool, string)
+
+// Match adapts the predicate to e2e-framework's ResourceMatch and the matchers of this package
+func (p Predicate) Match() func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		ok, _ := p(obj)
+		return ok
+	}
+}
+
+// Explain turns a bare matcher, e.g. VMReady(), into a Predicate described by description
+func Explain(description string, match func(obj k8s.Object) bool) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		if match(obj) {
+			return true, description
+		}
+		return false, "not " + description
+	}
+}
+
+// ConditionIs is the Predicate form of ConditionMatch
+func ConditionIs(exp ConditionExpectation) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		return matchCondition(obj, exp)
+	}
+}
+
+func VMIPhaseIs(phase kubev1.VirtualMachineInstancePhase) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false, fmt.Sprintf("%T is not a VirtualMachineInstance", obj)
+		}
+		if vmi.Status.Phase != phase {
+			return false, fmt.Sprintf("VMI phase %s, want %s", vmi.Status.Phase, phase)
+		}
+		return true, fmt.Sprintf("VMI phase %s", phase)
+	}
+}
+
+func PodPhaseIs(phase corev1.PodPhase) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return false, fmt.Sprintf("%T is not a Pod", obj)
+		}
+		if pod.Status.Phase != phase {
+			return false, fmt.Sprintf("Pod phase %s, want %s", pod.Status.Phase, phase)
+		}
+		return true, fmt.Sprintf("Pod phase %s", phase)
+	}
+}
+
+// NodeHealthy matches a node without any violation of the policy, the reason lists all of them
+func NodeHealthy(policy nodeutils.NodeHealthPolicy) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		node, ok := obj.(*corev1.Node)
+		if !ok {
+			return false, fmt.Sprintf("%T is not a Node", obj)
+		}
+		violations := policy.Check(node, time.Now())
+		if len(violations) == 0 {
+			return true, "node healthy"
+		}
+		reasons := make([]string, 0, len(violations))
+		for _, v := range violations {
+			reasons = append(reasons, fmt.Sprintf("condition %s %s", v.Condition, v.Message))
+		}
+		return false, strings.Join(reasons, "; ")
+	}
+}
+
+// NodePerfect matches a Ready node without memory, disk or PID pressure and with its network available
+func NodePerfect() Predicate {
+	return NodeHealthy(nodeutils.DefaultNodeHealthPolicy())
+}
+
+// DaemonSetReadyIs matches a DaemonSet whose current spec is rolled out and Ready on every node it is scheduled to
+func DaemonSetReadyIs() Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		ds, ok := obj.(*appsv1.DaemonSet)
+		if !ok {
+			return false, fmt.Sprintf("%T is not a DaemonSet", obj)
+		}
+		status := ds.Status
+		observed := fmt.Sprintf("DaemonSet %d/%d ready, %d updated, %d unavailable", status.NumberReady, status.DesiredNumberScheduled, status.UpdatedNumberScheduled, status.NumberUnavailable)
+		if status.ObservedGeneration != ds.Generation {
+			return false, fmt.Sprintf("%s, observed generation %d, want generation %d", observed, status.ObservedGeneration, ds.Generation)
+		}
+		if status.DesiredNumberScheduled == 0 || status.NumberReady != status.DesiredNumberScheduled ||
+			status.UpdatedNumberScheduled != status.DesiredNumberScheduled || status.NumberUnavailable != 0 {
+			return false, observed
+		}
+		return true, observed
+	}
+}
+
+// PodRestartsAtMost matches a pod whose containers restarted at most restarts times in total,
+// a pod which crash-loops between two samples of a readiness check still increases the count
+func PodRestartsAtMost(restarts int32) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return false, fmt.Sprintf("%T is not a Pod", obj)
+		}
+		var total int32
+		for _, status := range pod.Status.ContainerStatuses {
+			total += status.RestartCount
+		}
+		if total > restarts {
+			return false, fmt.Sprintf("Pod restarted %d times, want at most %d", total, restarts)
+		}
+		return true, fmt.Sprintf("Pod restarted %d times", total)
+	}
+}
+
+// All matches when every predicate matches, the reason lists the ones which did not
+func All(predicates ...Predicate) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		var matched, mismatched []string
+		for _, p := range predicates {
+			if ok, reason := p(obj); ok {
+				matched = append(matched, reason)
+			} else {
+				mismatched = append(mismatched, reason)
+			}
+		}
+		if len(mismatched) > 0 {
+			return false, strings.Join(mismatched, "; ")
+		}
+		return true, strings.Join(matched, "; ")
+	}
+}
+
+// Any matches when at least one predicate matches, the reason lists all of them when none does
+func Any(predicates ...Predicate) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		var mismatched []string
+		for _, p := range predicates {
+			ok, reason := p(obj)
+			if ok {
+				return true, reason
+			}
+			mismatched = append(mismatched, reason)
+		}
+		return false, "none of: " + strings.Join(mismatched, "; ")
+	}
+}
+
+func Not(p Predicate) Predicate {
+	return func(obj k8s.Object) (bool, string) {
+		ok, reason := p(obj)
+		if ok {
+			return false, fmt.Sprintf("%s, want the opposite", reason)
+		}
+		return true, reason
+	}
+}
+
+// Eventually polls the object until the predicate matches. On timeout the error carries the last
+// observed mismatch, e.g. "VMI phase Scheduling, want Running; condition Ready=False reason=GuestNotRunning"
+func Eventually(obj k8s.Object, p Predicate, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		return eventually(ctx, clientGetter(c), obj, p, timeout, interval)
+	}
+}
+
+func eventually(ctx context.Context, get getter, obj k8s.Object, p Predicate, timeout, interval time.Duration) error {
+	var lastReason string
+	err := wait.For(func(ctx context.Context) (bool, error) {
+		ok, reason, err := evaluate(ctx, get, obj, p)
+		if err != nil {
+			return false, err
+		}
+		lastReason = reason
+		return ok, nil
+	},
+		wait.WithTimeout(timeout),
+		wait.WithInterval(interval))
+	if err != nil {
+		return fmt.Errorf("%s %s did not match within %s: %v, last observed: %s", kindOf(obj), obj.GetName(), timeout, err, lastReason)
+	}
+	return nil
+}
+
+// Consistently polls the object for the whole duration and fails as soon as the predicate does not match,
+// with what was observed at that moment. Use it to tell a stable object from one which matched on a lucky
+// sample, e.g. a flapping node or a crash-looping pod
+func Consistently(obj k8s.Object, p Predicate, duration, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		start := time.Now()
+		deadline := start.Add(duration)
+		for {
+			ok, reason, err := evaluate(ctx, clientGetter(c), obj, p)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return fmt.Errorf("%s %s stopped matching after %s: %s", kindOf(obj), obj.GetName(), time.Since(start).Round(time.Second), reason)
+			}
+			if time.Now().After(deadline) {
+				return nil
+			}
+
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(interval):
+			}
+		}
+	}
+}
+
+// ConsistentlyAll runs Consistently on every object at once, so the window lasts duration whatever the number
+// of objects. The error joins the failures of all objects
+func ConsistentlyAll(objs []k8s.Object, p Predicate, duration, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		errs := make([]error, len(objs))
+		var wg sync.WaitGroup
+		for i, obj := range objs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs[i] = Consistently(obj, p, duration, interval)(ctx, c)
+			}()
+		}
+		wg.Wait()
+		return errors.Join(errs...)
+	}
+}
+
+// Refreshes obj in place from the API server
+type getter func(ctx context.Context, obj k8s.Object) error
+
+func clientGetter(c *envconf.Config) getter {
+	return func(ctx context.Context, obj k8s.Object) error {
+		return c.Client().Resources().Get(ctx, obj.GetName(), obj.GetNamespace(), obj)
+	}
+}
+
+// A missing object does not match rather than failing the wait, it might not be created yet
+func evaluate(ctx context.Context, get getter, obj k8s.Object, p Predicate) (bool, string, error) {
+	if err := get(ctx, obj); err != nil {
+		if apierrors.IsNotFound(err) {
+			return false, "not found", nil
+		}
+		return false, "", err
+	}
+	ok, reason := p(obj)
+	return ok, reason, nil
+}
+
+func kindOf(obj k8s.Object) string {
+	if kind := obj.GetObjectKind().GroupVersionKind().Kind; kind != "" {
+		return kind
+	}
+	return strings.TrimPrefix(fmt.Sprintf("%T", obj), "*")
+}
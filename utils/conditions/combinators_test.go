@@ -0,0 +1,166 @@
+package conditions
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	kubev1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func testPod(phase corev1.PodPhase, restarts int32) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "virt-launcher-0", Namespace: "default"},
+		Status: corev1.PodStatus{
+			Phase:             phase,
+			ContainerStatuses: []corev1.ContainerStatus{{Name: "compute", RestartCount: restarts}},
+		},
+	}
+}
+
+func TestCombinators(t *testing.T) {
+	always := func(obj k8s.Object) bool { return true }
+	never := func(obj k8s.Object) bool { return false }
+
+	tests := []struct {
+		name       string
+		p          Predicate
+		wantMatch  bool
+		wantReason string
+	}{
+		{name: "explained match", p: Explain("pod scheduled", always), wantMatch: true, wantReason: "pod scheduled"},
+		{name: "explained mismatch", p: Explain("pod scheduled", never), wantReason: "not pod scheduled"},
+		{
+			name:       "all match",
+			p:          All(PodPhaseIs(corev1.PodRunning), PodRestartsAtMost(5)),
+			wantMatch:  true,
+			wantReason: "Pod phase Running; Pod restarted 3 times",
+		},
+		{
+			name:       "all lists only the mismatches",
+			p:          All(PodPhaseIs(corev1.PodSucceeded), PodRestartsAtMost(5), PodRestartsAtMost(1)),
+			wantReason: "Pod phase Running, want Succeeded; Pod restarted 3 times, want at most 1",
+		},
+		{
+			name:       "any takes the first match",
+			p:          Any(PodPhaseIs(corev1.PodPending), PodPhaseIs(corev1.PodRunning), PodRestartsAtMost(5)),
+			wantMatch:  true,
+			wantReason: "Pod phase Running",
+		},
+		{
+			name:       "any lists every mismatch",
+			p:          Any(PodPhaseIs(corev1.PodPending), VMIPhaseIs(kubev1.Running)),
+			wantReason: "none of: Pod phase Running, want Pending; *v1.Pod is not a VirtualMachineInstance",
+		},
+		{
+			name:       "not of a match",
+			p:          Not(PodPhaseIs(corev1.PodRunning)),
+			wantReason: "Pod phase Running, want the opposite",
+		},
+		{
+			name:       "not of a mismatch",
+			p:          Not(PodPhaseIs(corev1.PodFailed)),
+			wantMatch:  true,
+			wantReason: "Pod phase Running, want Failed",
+		},
+		{
+			name:       "all of any and not",
+			p:          All(Any(PodPhaseIs(corev1.PodPending), PodPhaseIs(corev1.PodRunning)), Not(PodRestartsAtMost(5))),
+			wantReason: "Pod restarted 3 times, want the opposite",
+		},
+		{
+			name:       "any of all and not",
+			p:          Any(All(PodPhaseIs(corev1.PodRunning), PodRestartsAtMost(1)), Not(PodPhaseIs(corev1.PodRunning))),
+			wantReason: "none of: Pod restarted 3 times, want at most 1; Pod phase Running, want the opposite",
+		},
+		{
+			name:       "not of all",
+			p:          Not(All(PodPhaseIs(corev1.PodRunning), Explain("pod scheduled", always))),
+			wantReason: "Pod phase Running; pod scheduled, want the opposite",
+		},
+	}
+
+	feat := features.New("Predicate combinators").
+		WithLabel("type", "Conditions").
+		Assess("Test combining predicates and their reasons", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					matched, reason := tt.p(testPod(corev1.PodRunning, 3))
+					if matched != tt.wantMatch || reason != tt.wantReason {
+						t.Errorf("got %t, %q, want %t, %q", matched, reason, tt.wantMatch, tt.wantReason)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestEventually(t *testing.T) {
+	// Each get returns the next phase, the last one from then on, and a missing pod for an empty phase
+	phases := func(sequence ...corev1.PodPhase) getter {
+		var calls int
+		return func(ctx context.Context, obj k8s.Object) error {
+			phase := sequence[min(calls, len(sequence)-1)]
+			calls++
+			if phase == "" {
+				return apierrors.NewNotFound(schema.GroupResource{Resource: "pods"}, obj.GetName())
+			}
+			obj.(*corev1.Pod).Status.Phase = phase
+			return nil
+		}
+	}
+
+	tests := []struct {
+		name string
+		get  getter
+		// Substrings of the expected error, none when the wait succeeds
+		wantErr []string
+	}{
+		{
+			name: "matches after a few polls",
+			get:  phases("", corev1.PodPending, corev1.PodRunning),
+		},
+		{
+			name:    "times out with the last mismatch",
+			get:     phases(corev1.PodPending, corev1.PodPending, corev1.PodFailed),
+			wantErr: []string{"Pod virt-launcher-0 did not match within 50ms", "last observed: Pod phase Failed, want Running"},
+		},
+		{
+			name:    "times out on a missing object",
+			get:     phases(""),
+			wantErr: []string{"last observed: not found"},
+		},
+	}
+
+	feat := features.New("Predicate waits").
+		WithLabel("type", "Conditions").
+		Assess("Test waiting for a predicate and the timeout error", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					pod := testPod("", 0)
+					err := eventually(ctx, tt.get, pod, PodPhaseIs(corev1.PodRunning), 50*time.Millisecond, 5*time.Millisecond)
+					if (err != nil) != (len(tt.wantErr) > 0) {
+						t.Fatalf("got error %v, want error %t", err, len(tt.wantErr) > 0)
+					}
+					for _, want := range tt.wantErr {
+						if !strings.Contains(err.Error(), want) {
+							t.Errorf("error %q does not contain %q", err, want)
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
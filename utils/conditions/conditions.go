@@ -1,8 +1,12 @@
 package conditions
 
 import (
+	"slices"
+
 	corev1 "k8s.io/api/core/v1"
+	clonev1alpha1 "kubevirt.io/api/clone/v1alpha1"
 	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
 	"sigs.k8s.io/e2e-framework/klient/k8s"
 )
 
@@ -60,3 +64,178 @@ func VMIReady() func(obj k8s.Object) bool {
 func VMIRunning() func(obj k8s.Object) bool {
 	return VMIPhaseMatch(kubev1.Running)
 }
+
+// The guest agent is connected once the guest OS booted and started qemu-guest-agent
+func VMIAgentConnected() func(obj k8s.Object) bool {
+	return VMIConditionMatch(kubev1.VirtualMachineInstanceAgentConnected, corev1.ConditionTrue)
+}
+
+// Matches once the guest agent reported the OS info. An empty id matches any OS, otherwise it is
+// compared with the os-release ID, e.g. rhel or fedora
+func VMIGuestOSInfoMatch(id string) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false
+		}
+
+		if vmi.Status.GuestOSInfo.ID == "" {
+			return false
+		}
+		return id == "" || vmi.Status.GuestOSInfo.ID == id
+	}
+}
+
+// Matches once the interface reports an IP. An empty ifaceName matches any interface and an empty ip matches any IP
+func VMIInterfaceIPMatch(ifaceName, ip string) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false
+		}
+
+		for _, iface := range vmi.Status.Interfaces {
+			if ifaceName != "" && iface.Name != ifaceName {
+				continue
+			}
+			if iface.IP == "" {
+				continue
+			}
+			if ip == "" || iface.IP == ip || slices.Contains(iface.IPs, ip) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// VMI status reports the QoS class of its virt-launcher pod
+func VMIQOSClassMatch(class corev1.PodQOSClass) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false
+		}
+
+		return vmi.Status.QOSClass != nil && *vmi.Status.QOSClass == class
+	}
+}
+
+func PodQOSClassMatch(class corev1.PodQOSClass) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok {
+			return false
+		}
+
+		return pod.Status.QOSClass == class
+	}
+}
+
+func VMClonePhaseMatch(phase clonev1alpha1.VirtualMachineClonePhase) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmc, ok := obj.(*clonev1alpha1.VirtualMachineClone)
+		if !ok {
+			return false
+		}
+
+		return vmc.Status.Phase == phase
+	}
+}
+
+func VMCloneSucceeded() func(obj k8s.Object) bool {
+	return VMClonePhaseMatch(clonev1alpha1.Succeeded)
+}
+
+// Matches once the VMI reports the hotplugged volume Ready, i.e. attached to the guest
+func VMIVolumeReady(volumeName string) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false
+		}
+
+		for _, vs := range vmi.Status.VolumeStatus {
+			if vs.Name == volumeName {
+				return vs.Phase == kubev1.VolumeReady
+			}
+		}
+		return false
+	}
+}
+
+// Matches once the VMI no longer reports the volume, i.e. it was unplugged and detached
+func VMIVolumeRemoved(volumeName string) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		vmi, ok := obj.(*kubev1.VirtualMachineInstance)
+		if !ok {
+			return false
+		}
+
+		for _, vs := range vmi.Status.VolumeStatus {
+			if vs.Name == volumeName {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+func DataVolumePhaseMatch(phases ...cdiv1beta1.DataVolumePhase) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		dv, ok := obj.(*cdiv1beta1.DataVolume)
+		if !ok {
+			return false
+		}
+
+		return slices.Contains(phases, dv.Status.Phase)
+	}
+}
+
+func DataVolumeConditionMatch(conditionType cdiv1beta1.DataVolumeConditionType, conditionStatus corev1.ConditionStatus) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		dv, ok := obj.(*cdiv1beta1.DataVolume)
+		if !ok {
+			return false
+		}
+
+		for _, cond := range dv.Status.Conditions {
+			if cond.Type == conditionType {
+				return cond.Status == conditionStatus
+			}
+		}
+		return false
+	}
+}
+
+func DataVolumeImportScheduled() func(obj k8s.Object) bool {
+	return DataVolumePhaseMatch(cdiv1beta1.ImportScheduled)
+}
+
+// CDI reports a different phase for every clone strategy, host assisted, smart and CSI clones all match
+func DataVolumeCloneInProgress() func(obj k8s.Object) bool {
+	return DataVolumePhaseMatch(
+		cdiv1beta1.CloneInProgress,
+		cdiv1beta1.SnapshotForSmartCloneInProgress,
+		cdiv1beta1.CloneFromSnapshotSourceInProgress,
+		cdiv1beta1.SmartClonePVCInProgress,
+		cdiv1beta1.CSICloneInProgress,
+	)
+}
+
+// The storage class binds volumes only once a pod consumes them, e.g. the virt-launcher pod of the VM
+func DataVolumeWaitForFirstConsumer() func(obj k8s.Object) bool {
+	return DataVolumePhaseMatch(cdiv1beta1.WaitForFirstConsumer)
+}
+
+func DataVolumeSucceeded() func(obj k8s.Object) bool {
+	return DataVolumePhaseMatch(cdiv1beta1.Succeeded)
+}
+
+func DataVolumeFailed() func(obj k8s.Object) bool {
+	return DataVolumePhaseMatch(cdiv1beta1.Failed)
+}
+
+func DataVolumeReady() func(obj k8s.Object) bool {
+	return DataVolumeConditionMatch(cdiv1beta1.DataVolumeReady, corev1.ConditionTrue)
+}
@@ -0,0 +1,135 @@
+package conditions
+
+import (
+	"fmt"
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+)
+
+// Condition is the common shape of status.conditions entries across Kubernetes, KubeVirt, CDI
+// and most operators, whatever the Go type of the object
+type Condition struct {
+	Type    string
+	Status  corev1.ConditionStatus
+	Reason  string
+	Message string
+	// Zero when the condition does not report it
+	ObservedGeneration int64
+}
+
+// ConditionExpectation describes the condition to wait for. Empty fields match any value
+type ConditionExpectation struct {
+	Type   string
+	Status corev1.ConditionStatus
+	Reason string
+	// Matched against the condition message, e.g. regexp.MustCompile("(?i)quota")
+	MessageRegex *regexp.Regexp
+	// Require the condition to be about the current spec: its observedGeneration, or the one of the
+	// status when the condition has none, must equal metadata.generation. Objects reporting neither match
+	Fresh bool
+}
+
+// ConditionMatch matches typed objects, e.g. *corev1.Node or *cdiv1beta1.DataVolume, as well as
+// *unstructured.Unstructured ones for CRDs without Go types in this module
+func ConditionMatch(exp ConditionExpectation) func(obj k8s.Object) bool {
+	return func(obj k8s.Object) bool {
+		ok, _ := matchCondition(obj, exp)
+		return ok
+	}
+}
+
+// GetConditions reads status.conditions of any object
+func GetConditions(obj k8s.Object) ([]Condition, error) {
+	content, err := toUnstructured(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, found, err := unstructured.NestedSlice(content, "status", "conditions")
+	if err != nil || !found {
+		return nil, err
+	}
+
+	conditions := make([]Condition, 0, len(raw))
+	for _, item := range raw {
+		fields, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		cond := Condition{}
+		cond.Type, _, _ = unstructured.NestedString(fields, "type")
+		status, _, _ := unstructured.NestedString(fields, "status")
+		cond.Status = corev1.ConditionStatus(status)
+		cond.Reason, _, _ = unstructured.NestedString(fields, "reason")
+		cond.Message, _, _ = unstructured.NestedString(fields, "message")
+		cond.ObservedGeneration, _, _ = unstructured.NestedInt64(fields, "observedGeneration")
+		conditions = append(conditions, cond)
+	}
+	return conditions, nil
+}
+
+// GetCondition returns the condition of the given type, or nil when the object does not report it
+func GetCondition(obj k8s.Object, conditionType string) (*Condition, error) {
+	conditions, err := GetConditions(obj)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conditions {
+		if conditions[i].Type == conditionType {
+			return &conditions[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// Returns whether the object matches and, when it does not, what was observed instead
+func matchCondition(obj k8s.Object, exp ConditionExpectation) (bool, string) {
+	cond, err := GetCondition(obj, exp.Type)
+	if err != nil {
+		return false, fmt.Sprintf("failed to read conditions: %v", err)
+	}
+	if cond == nil {
+		return false, fmt.Sprintf("condition %s not reported", exp.Type)
+	}
+
+	observed := fmt.Sprintf("condition %s=%s reason=%s", cond.Type, cond.Status, cond.Reason)
+	if exp.Status != "" && cond.Status != exp.Status {
+		return false, fmt.Sprintf("%s, want status %s", observed, exp.Status)
+	}
+	if exp.Reason != "" && cond.Reason != exp.Reason {
+		return false, fmt.Sprintf("%s, want reason %s", observed, exp.Reason)
+	}
+	if exp.MessageRegex != nil && !exp.MessageRegex.MatchString(cond.Message) {
+		return false, fmt.Sprintf("%s message %q, want message matching %q", observed, cond.Message, exp.MessageRegex)
+	}
+	if exp.Fresh {
+		observedGeneration := cond.ObservedGeneration
+		if observedGeneration == 0 {
+			observedGeneration = statusObservedGeneration(obj)
+		}
+		if observedGeneration != obj.GetGeneration() {
+			return false, fmt.Sprintf("%s observed generation %d, want generation %d", observed, observedGeneration, obj.GetGeneration())
+		}
+	}
+	return true, observed
+}
+
+func statusObservedGeneration(obj k8s.Object) int64 {
+	content, err := toUnstructured(obj)
+	if err != nil {
+		return 0
+	}
+	observedGeneration, _, _ := unstructured.NestedInt64(content, "status", "observedGeneration")
+	return observedGeneration
+}
+
+func toUnstructured(obj k8s.Object) (map[string]interface{}, error) {
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		return u.Object, nil
+	}
+	return runtime.DefaultUnstructuredConverter.ToUnstructured(obj)
+}
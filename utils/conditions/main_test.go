@@ -0,0 +1,25 @@
+package conditions
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+var testsEnvironment env.Environment
+
+func TestMain(m *testing.M) {
+	// create config from flags (always in TestMain or init handler of the package before calling envconf.NewFromFlags())
+	// This is needed in order to initilize flags provided by the e2e-framework module
+	cfg, err := envconf.NewFromFlags()
+	if err != nil {
+		fmt.Printf("failed to build envconf from flags: %s", err)
+		os.Exit(1)
+	}
+	testsEnvironment = env.NewWithConfig(cfg)
+
+	os.Exit(testsEnvironment.Run(m))
+}
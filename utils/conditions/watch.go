@@ -0,0 +1,198 @@
+package conditions
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Used only after the watch broke, e.g. the resourceVersion expired or the API server went away
+	fallbackPollInterval time.Duration = 5 * time.Second
+)
+
+// Transition is a change of the predicate's result or reason, timed when the change was received
+type Transition struct {
+	Time            time.Time
+	Matched         bool
+	Reason          string
+	ResourceVersion string
+}
+
+// WatchResult holds every transition observed until the predicate matched, the first one being the initial state
+type WatchResult struct {
+	Transitions []Transition
+}
+
+// Time the predicate started to match, zero when it never did
+func (r *WatchResult) MatchedAt() time.Time {
+	if n := len(r.Transitions); n > 0 && r.Transitions[n-1].Matched {
+		return r.Transitions[n-1].Time
+	}
+	return time.Time{}
+}
+
+func (r *WatchResult) record(matched bool, reason, resourceVersion string) {
+	if n := len(r.Transitions); n > 0 && r.Transitions[n-1].Matched == matched && r.Transitions[n-1].Reason == reason {
+		return
+	}
+	r.Transitions = append(r.Transitions, Transition{Time: time.Now(), Matched: matched, Reason: reason, ResourceVersion: resourceVersion})
+}
+
+func (r *WatchResult) lastReason() string {
+	if n := len(r.Transitions); n > 0 {
+		return r.Transitions[n-1].Reason
+	}
+	return "nothing observed"
+}
+
+// WatchUntil waits for the predicate to match the object, evaluating it on every watch event rather than
+// on a fixed interval, so the wait ends as soon as the object changes and the API server is not polled.
+// The watch resumes from the last seen resourceVersion when it is closed, and falls back to polling if it
+// can not be resumed. The object's type must be registered in the client's scheme
+func WatchUntil(obj k8s.Object, p Predicate, timeout time.Duration) func(ctx context.Context, c *envconf.Config) (*WatchResult, error) {
+	return func(ctx context.Context, c *envconf.Config) (*WatchResult, error) {
+		result := &WatchResult{}
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+
+		resource, err := ResourceFor(c, obj)
+		if err != nil {
+			return result, err
+		}
+		nameSelector := fields.OneTermEqualSelector("metadata.name", obj.GetName()).String()
+
+		// Listing rather than getting returns the resourceVersion the watch has to start from
+		list, err := resource.List(ctx, metav1.ListOptions{FieldSelector: nameSelector})
+		if err != nil {
+			return result, err
+		}
+		if len(list.Items) == 0 {
+			result.record(false, "not found", list.GetResourceVersion())
+		} else if matched, err := evaluateUnstructured(&list.Items[0], obj, p, result); err != nil || matched {
+			return result, err
+		}
+
+		watcher, err := watchtools.NewRetryWatcher(list.GetResourceVersion(), &cache.ListWatch{
+			WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+				options.FieldSelector = nameSelector
+				return resource.Watch(ctx, options)
+			},
+		})
+		if err != nil {
+			return result, err
+		}
+		defer watcher.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return result, fmt.Errorf("%s %s did not match within %s, last observed: %s", kindOf(obj), obj.GetName(), timeout, result.lastReason())
+			case <-watcher.Done():
+				return result, pollUntil(ctx, c, obj, p, result, timeout)
+			case event, ok := <-watcher.ResultChan():
+				if !ok {
+					return result, pollUntil(ctx, c, obj, p, result, timeout)
+				}
+				switch event.Type {
+				case watch.Added, watch.Modified:
+					u, ok := event.Object.(*unstructured.Unstructured)
+					if !ok {
+						continue
+					}
+					if matched, err := evaluateUnstructured(u, obj, p, result); err != nil || matched {
+						return result, err
+					}
+				case watch.Deleted:
+					result.record(false, "deleted", "")
+				case watch.Error:
+					// The RetryWatcher gives up on errors it can not resume from, e.g. 410 Gone
+					return result, pollUntil(ctx, c, obj, p, result, timeout)
+				}
+			}
+		}
+	}
+}
+
+// Decodes the event into obj, so the predicate sees the same typed object as with polling
+func evaluateUnstructured(u *unstructured.Unstructured, obj k8s.Object, p Predicate, result *WatchResult) (bool, error) {
+	if typed, ok := obj.(*unstructured.Unstructured); ok {
+		u.DeepCopyInto(typed)
+	} else if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return false, fmt.Errorf("failed to decode %s %s: %v", u.GetKind(), u.GetName(), err)
+	}
+	matched, reason := p(obj)
+	result.record(matched, reason, u.GetResourceVersion())
+	return matched, nil
+}
+
+func pollUntil(ctx context.Context, c *envconf.Config, obj k8s.Object, p Predicate, result *WatchResult, timeout time.Duration) error {
+	for {
+		if err := c.Client().Resources().Get(ctx, obj.GetName(), obj.GetNamespace(), obj); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		} else if err != nil {
+			result.record(false, "not found", "")
+		} else {
+			matched, reason := p(obj)
+			result.record(matched, reason, obj.GetResourceVersion())
+			if matched {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("%s %s did not match within %s, last observed: %s", kindOf(obj), obj.GetName(), timeout, result.lastReason())
+		case <-time.After(fallbackPollInterval):
+		}
+	}
+}
+
+// ResourceFor returns a dynamic client for the resource of the object, resolved through its kind in the
+// client's scheme and API discovery, scoped to the namespace of the object when it has one
+func ResourceFor(c *envconf.Config, obj k8s.Object) (dynamic.ResourceInterface, error) {
+	gvk := obj.GetObjectKind().GroupVersionKind()
+	if _, ok := obj.(*unstructured.Unstructured); !ok {
+		gvks, _, err := c.Client().Resources().GetScheme().ObjectKinds(obj)
+		if err != nil {
+			return nil, err
+		}
+		gvk = gvks[0]
+	}
+
+	cfg := c.Client().RESTConfig()
+	discoveryClient, err := discovery.NewDiscoveryClientForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(discoveryClient))
+	mapping, err := mapper.RESTMapping(schema.GroupKind{Group: gvk.Group, Kind: gvk.Kind}, gvk.Version)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve the resource of %s: %v", gvk, err)
+	}
+
+	dynamicClient, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if obj.GetNamespace() == "" {
+		return dynamicClient.Resource(mapping.Resource), nil
+	}
+	return dynamicClient.Resource(mapping.Resource).Namespace(obj.GetNamespace()), nil
+}
@@ -0,0 +1,87 @@
+package conditions
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestWatchResult(t *testing.T) {
+	type observation struct {
+		matched bool
+		reason  string
+	}
+
+	tests := []struct {
+		name         string
+		observations []observation
+		// Reasons of the expected transitions, in order
+		want        []string
+		wantMatched bool
+	}{
+		{name: "nothing observed"},
+		{
+			name:         "repeated mismatch is a single transition",
+			observations: []observation{{false, "not found"}, {false, "not found"}, {false, "not found"}},
+			want:         []string{"not found"},
+		},
+		{
+			name:         "new reason is a transition",
+			observations: []observation{{false, "not found"}, {false, "VMI phase Scheduling, want Running"}, {false, "VMI phase Scheduling, want Running"}},
+			want:         []string{"not found", "VMI phase Scheduling, want Running"},
+		},
+		{
+			name:         "match",
+			observations: []observation{{false, "VMI phase Scheduled, want Running"}, {true, "VMI phase Running"}, {true, "VMI phase Running"}},
+			want:         []string{"VMI phase Scheduled, want Running", "VMI phase Running"},
+			wantMatched:  true,
+		},
+		{
+			name:         "same reason with another result",
+			observations: []observation{{true, "Pod restarted 0 times"}, {false, "Pod restarted 0 times"}},
+			want:         []string{"Pod restarted 0 times", "Pod restarted 0 times"},
+		},
+		{
+			name:         "deleted after matching",
+			observations: []observation{{true, "VMI phase Running"}, {false, "deleted"}},
+			want:         []string{"VMI phase Running", "deleted"},
+		},
+	}
+
+	feat := features.New("Watch results").
+		WithLabel("type", "Conditions").
+		Assess("Test recording transitions and the time the predicate matched", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					result := &WatchResult{}
+					for _, o := range tt.observations {
+						result.record(o.matched, o.reason, "")
+					}
+
+					if len(result.Transitions) != len(tt.want) {
+						t.Fatalf("got %d transitions, want %d: %v", len(result.Transitions), len(tt.want), result.Transitions)
+					}
+					for i, reason := range tt.want {
+						if result.Transitions[i].Reason != reason {
+							t.Errorf("transition %d is %q, want %q", i, result.Transitions[i].Reason, reason)
+						}
+					}
+
+					matchedAt := result.MatchedAt()
+					if tt.wantMatched {
+						// The time of the transition to the match, not of a later observation
+						if last := result.Transitions[len(result.Transitions)-1]; !last.Matched || !matchedAt.Equal(last.Time) {
+							t.Errorf("matched at %s, want %s", matchedAt, last.Time)
+						}
+					} else if !matchedAt.IsZero() {
+						t.Errorf("matched at %s, want never", matchedAt)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,138 @@
+package datavolume
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"node-e2e/utils"
+	"node-e2e/utils/pod"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Any image with a shell, head and sha256sum will do
+	verifierImage     string = "registry.access.redhat.com/ubi9/ubi-minimal:latest"
+	verifierVolume    string = "image"
+	verifierMountPath string = "/pvc"
+	verifierDevice    string = "/dev/image"
+	// CDI writes the imported image to this file on Filesystem volumes
+	diskImageName string = "disk.img"
+)
+
+var sha256Regex = regexp.MustCompile(`\b([0-9a-f]{64})\b`)
+
+// VerifyChecksum runs a short-lived pod which mounts the PVC of the DataVolume, named after it, and computes
+// the sha256 of the imported disk image, disk.img on Filesystem volumes and the device itself on Block volumes.
+// CDI stores images raw, so expectedSHA256 is the digest of the raw image. Block devices are usually larger
+// than the image, with size not zero only the first size bytes are hashed.
+// The PVC must not be in use by a pod on another node, e.g. a running VM, when its access mode is ReadWriteOnce.
+// Returns the computed digest, and an error if it does not match
+func VerifyChecksum(pvcName, ns, expectedSHA256 string, size int64, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) (string, error) {
+	return func(ctx context.Context, c *envconf.Config) (string, error) {
+		var pvc corev1.PersistentVolumeClaim
+		if err := c.Client().Resources(ns).Get(ctx, pvcName, ns, &pvc); err != nil {
+			return "", err
+		}
+
+		verifier := genVerifierPod(pvcName, ns, pvc.Spec.VolumeMode, size)
+		if err := c.Client().Resources(ns).Create(ctx, verifier); err != nil {
+			return "", fmt.Errorf("failed to create checksum verifier pod for %s: %v", pvcName, err)
+		}
+		defer func() {
+			var gracePeriodSeconds int64 = 0
+			c.Client().Resources(ns).Delete(context.Background(), verifier, resources.WithGracePeriod(time.Duration(gracePeriodSeconds)*time.Second))
+		}()
+
+		// The pod is never restarted, so either phase is final
+		if err := wait.For(conditions.New(c.Client().Resources(ns)).ResourceMatch(verifier, func(obj k8s.Object) bool {
+			phase := obj.(*corev1.Pod).Status.Phase
+			return phase == corev1.PodSucceeded || phase == corev1.PodFailed
+		}),
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval)); err != nil {
+			return "", fmt.Errorf("checksum verifier pod for %s did not finish: %v", pvcName, err)
+		}
+
+		clientset, err := kubernetes.NewForConfig(c.Client().RESTConfig())
+		if err != nil {
+			return "", err
+		}
+		logs, err := clientset.CoreV1().Pods(ns).GetLogs(verifier.Name, &corev1.PodLogOptions{}).DoRaw(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get logs of checksum verifier pod %s: %v", verifier.Name, err)
+		}
+		if verifier.Status.Phase == corev1.PodFailed {
+			return "", fmt.Errorf("checksum verifier pod for %s failed: %s", pvcName, strings.TrimSpace(string(logs)))
+		}
+
+		match := sha256Regex.FindStringSubmatch(string(logs))
+		if match == nil {
+			return "", fmt.Errorf("no sha256 digest in the output of checksum verifier pod %s: %q", verifier.Name, logs)
+		}
+		digest := match[1]
+		if !strings.EqualFold(digest, expectedSHA256) {
+			return digest, fmt.Errorf("content of %s not as expected: expected sha256 %s, got %s", pvcName, expectedSHA256, digest)
+		}
+		return digest, nil
+	}
+}
+
+func genVerifierPod(pvcName, ns string, volumeMode *corev1.PersistentVolumeMode, size int64) *corev1.Pod {
+	block := volumeMode != nil && *volumeMode == corev1.PersistentVolumeBlock
+
+	imagePath := verifierMountPath + "/" + diskImageName
+	var mounts []corev1.VolumeMount
+	if block {
+		imagePath = verifierDevice
+	} else {
+		mounts = []corev1.VolumeMount{{Name: verifierVolume, MountPath: verifierMountPath, ReadOnly: true}}
+	}
+	script := fmt.Sprintf("sha256sum %s", imagePath)
+	if size > 0 {
+		script = fmt.Sprintf("head -c %d %s | sha256sum", size, imagePath)
+	}
+
+	container := pod.GenDefaultContainer(
+		"verifier",
+		verifierImage,
+		mounts,
+		nil,
+		corev1.ResourceRequirements{
+			Requests: *utils.GenerateResourceList("100m", "64Mi", "", ""),
+		},
+		"/bin/sh", "-c", "set -o pipefail; "+script,
+	)
+	if block {
+		container.VolumeDevices = []corev1.VolumeDevice{{Name: verifierVolume, DevicePath: verifierDevice}}
+	}
+
+	volumes := []corev1.Volume{
+		{
+			Name: verifierVolume,
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvcName,
+					ReadOnly:  true,
+				},
+			},
+		},
+	}
+	verifier := pod.GenDefaultPod(ns, "", volumes, *container)
+	verifier.ObjectMeta = metav1.ObjectMeta{
+		GenerateName: pvcName + "-verifier-",
+		Namespace:    ns,
+	}
+	verifier.Spec.RestartPolicy = corev1.RestartPolicyNever
+	return verifier
+}
@@ -0,0 +1,58 @@
+package datavolume
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// CDI reads the same keys for HTTP basic auth, S3 and ImageIO credentials
+	accessKeyIDKey string = "accessKeyId"
+	secretKeyKey   string = "secretKey"
+	// CDI accepts any key in the cert ConfigMap, the name only documents the content
+	caBundleKey string = "ca.pem"
+)
+
+// Secret referenced by the SecretRef of HTTP, S3 and ImageIO sources. For HTTP the pair is the
+// basic auth user and password, for S3 the access key ID and secret access key
+func GenerateSourceCredentialsSecret(name, ns, accessKeyID, secretKey string) *corev1.Secret {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Type: corev1.SecretTypeOpaque,
+		StringData: map[string]string{
+			accessKeyIDKey: accessKeyID,
+			secretKeyKey:   secretKey,
+		},
+	}
+	return &secret
+}
+
+// Secret referenced by SecretExtraHeaders of HTTP sources, every value is a "Name: value" header
+func GenerateExtraHeadersSecret(name, ns string, headers map[string]string) *corev1.Secret {
+	secret := corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Type:       corev1.SecretTypeOpaque,
+		StringData: headers,
+	}
+	return &secret
+}
+
+// ConfigMap referenced by the CertConfigMap of HTTP, S3 and ImageIO sources, caBundle is PEM encoded
+func GenerateCertConfigMap(name, ns, caBundle string) *corev1.ConfigMap {
+	cm := corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Data: map[string]string{
+			caBundleKey: caBundle,
+		},
+	}
+	return &cm
+}
@@ -10,10 +10,17 @@ import (
 )
 
 type DataVolumeData struct {
-	DVSource         *cdiv1beta1.DataVolumeSource
-	PVAccessMode     corev1.PersistentVolumeAccessMode
+	DVSource *cdiv1beta1.DataVolumeSource
+	// Clone from a DataSource, e.g. a golden image kept up to date by a DataImportCron, instead of DVSource
+	SourceRef *cdiv1beta1.DataVolumeSourceRef
+	// Emit spec.storage rather than spec.pvc, CDI then fills access modes, volume mode and
+	// storage class left empty from the StorageProfile and the default storage class
+	UseStorage       bool
+	PVAccessModes    []corev1.PersistentVolumeAccessMode
 	StorageRequests  string
 	PVMode           corev1.PersistentVolumeMode
+	// Left out of the generated spec when empty, so the PVC gets the default storage class of the cluster.
+	// Use ResolveStorage to find out which one that is
 	StorageClassName string
 }
 
@@ -40,22 +47,54 @@ func GenerateDataVolume(dvname, ns string, dvdata DataVolumeData) *cdiv1beta1.Da
 
 func GenerateDataVolumeSpec(dvdata DataVolumeData) *cdiv1beta1.DataVolumeSpec {
 	dataVolumeSpec := cdiv1beta1.DataVolumeSpec{
-		Source: dvdata.DVSource,
-		PVC: &corev1.PersistentVolumeClaimSpec{
-			AccessModes: []corev1.PersistentVolumeAccessMode{
-				dvdata.PVAccessMode,
-			},
-			Resources: corev1.VolumeResourceRequirements{
+		Source:      dvdata.DVSource,
+		SourceRef:   dvdata.SourceRef,
+		ContentType: cdiv1beta1.DataVolumeKubeVirt, // Content type is "kubevirt"
+	}
+
+	var storageClassName *string
+	if dvdata.StorageClassName != "" {
+		storageClassName = &dvdata.StorageClassName
+	}
+	var volumeMode *corev1.PersistentVolumeMode
+	if dvdata.PVMode != "" {
+		volumeMode = &dvdata.PVMode
+	}
+
+	if dvdata.UseStorage {
+		dataVolumeSpec.Storage = &cdiv1beta1.StorageSpec{
+			AccessModes: dvdata.PVAccessModes,
+			Resources: corev1.ResourceRequirements{
 				Requests: *utils.GenerateResourceList("", "", dvdata.StorageRequests, ""),
 			},
-			StorageClassName: &dvdata.StorageClassName,
-			VolumeMode:       &dvdata.PVMode,
+			StorageClassName: storageClassName,
+			VolumeMode:       volumeMode,
+		}
+		return &dataVolumeSpec
+	}
+
+	dataVolumeSpec.PVC = &corev1.PersistentVolumeClaimSpec{
+		AccessModes: dvdata.PVAccessModes,
+		Resources: corev1.VolumeResourceRequirements{
+			Requests: *utils.GenerateResourceList("", "", dvdata.StorageRequests, ""),
 		},
-		ContentType: cdiv1beta1.DataVolumeKubeVirt, // Content type is "kubevirt"
+		StorageClassName: storageClassName,
+		VolumeMode:       volumeMode,
 	}
 	return &dataVolumeSpec
 }
 
+// DataSources point at the current golden image, so the DataVolume does not depend on PVC names which
+// differ between clusters and change with every DataImportCron import
+func GenerateDataVolumeSourceRef(namespace, dataSourceName string) *cdiv1beta1.DataVolumeSourceRef {
+	sourceRef := &cdiv1beta1.DataVolumeSourceRef{
+		Kind:      cdiv1beta1.DataVolumeDataSource,
+		Namespace: &namespace,
+		Name:      dataSourceName,
+	}
+	return sourceRef
+}
+
 func GenerateDataVolumeSourceHTTP(sourceURL string) *cdiv1beta1.DataVolumeSource {
 	dvsource := &cdiv1beta1.DataVolumeSource{
 		HTTP: &cdiv1beta1.DataVolumeSourceHTTP{
@@ -65,6 +104,74 @@ func GenerateDataVolumeSourceHTTP(sourceURL string) *cdiv1beta1.DataVolumeSource
 	return dvsource
 }
 
+// HTTPSourceOptions configure authenticated and TLS imports, see GenerateDataVolumeSourceHTTPWithOptions
+type HTTPSourceOptions struct {
+	// Secret with accessKeyId and secretKey used as basic auth credentials
+	SecretRef string
+	// ConfigMap with the CA bundle the server's certificate is verified with
+	CertConfigMap string
+	// Headers in the "Name: value" format, e.g. "Cookie: session=abc"
+	ExtraHeaders []string
+	// Secrets holding headers in the same format, for headers which must not be stored in the DataVolume
+	SecretExtraHeaders []string
+}
+
+func GenerateDataVolumeSourceHTTPWithOptions(sourceURL string, opts HTTPSourceOptions) *cdiv1beta1.DataVolumeSource {
+	dvsource := &cdiv1beta1.DataVolumeSource{
+		HTTP: &cdiv1beta1.DataVolumeSourceHTTP{
+			URL:                sourceURL,
+			SecretRef:          opts.SecretRef,
+			CertConfigMap:      opts.CertConfigMap,
+			ExtraHeaders:       opts.ExtraHeaders,
+			SecretExtraHeaders: opts.SecretExtraHeaders,
+		},
+	}
+	return dvsource
+}
+
+// S3 sources also cover S3 compatible object stores, e.g. MinIO or Ceph RGW.
+// secretRef and certConfigMap may be empty for public buckets served with a trusted certificate
+func GenerateDataVolumeSourceS3(sourceURL, secretRef, certConfigMap string) *cdiv1beta1.DataVolumeSource {
+	dvsource := &cdiv1beta1.DataVolumeSource{
+		S3: &cdiv1beta1.DataVolumeSourceS3{
+			URL:           sourceURL,
+			SecretRef:     secretRef,
+			CertConfigMap: certConfigMap,
+		},
+	}
+	return dvsource
+}
+
+func GenerateDataVolumeSourceGCS(sourceURL, secretRef string) *cdiv1beta1.DataVolumeSource {
+	dvsource := &cdiv1beta1.DataVolumeSource{
+		GCS: &cdiv1beta1.DataVolumeSourceGCS{
+			URL:       sourceURL,
+			SecretRef: secretRef,
+		},
+	}
+	return dvsource
+}
+
+func GenerateDataVolumeSourceImageIO(sourceURL, diskID, secretRef, certConfigMap string) *cdiv1beta1.DataVolumeSource {
+	dvsource := &cdiv1beta1.DataVolumeSource{
+		Imageio: &cdiv1beta1.DataVolumeSourceImageIO{
+			URL:           sourceURL,
+			DiskID:        diskID,
+			SecretRef:     secretRef,
+			CertConfigMap: certConfigMap,
+		},
+	}
+	return dvsource
+}
+
+// The DataVolume waits in UploadReady until an image is uploaded through the CDI upload proxy, see UploadImage
+func GenerateDataVolumeSourceUpload() *cdiv1beta1.DataVolumeSource {
+	dvsource := &cdiv1beta1.DataVolumeSource{
+		Upload: &cdiv1beta1.DataVolumeSourceUpload{},
+	}
+	return dvsource
+}
+
 func GenerateDataVolumeSourceRegistry(sourceURL, pullMethod *string) *cdiv1beta1.DataVolumeSource {
 	dvsource := &cdiv1beta1.DataVolumeSource{
 		Registry: &cdiv1beta1.DataVolumeSourceRegistry{
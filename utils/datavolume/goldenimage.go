@@ -0,0 +1,95 @@
+package datavolume
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Namespace OpenShift Virtualization keeps its golden images in
+	GoldenImagesNamespace string = "openshift-virtualization-os-images"
+	// CDI labels DataSources managed by a DataImportCron with the name of the cron
+	labelDataImportCron string = "cdi.kubevirt.io/dataImportCron"
+)
+
+// CheckGoldenImage makes sure the DataSource can be cloned from right now, so suites fail at once with
+// the reason instead of timing out on a DataVolume which never starts cloning. The DataSource must be Ready
+// and its source PVC must exist. When a DataImportCron manages the DataSource and maxAge is not zero,
+// the cron must be UpToDate and its last import must be more recent than maxAge
+func CheckGoldenImage(dataSourceName, ns string, maxAge time.Duration) func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.DataSource, error) {
+	return func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.DataSource, error) {
+		var ds cdiv1beta1.DataSource
+		if err := c.Client().Resources(ns).Get(ctx, dataSourceName, ns, &ds); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil, fmt.Errorf("golden image missing: DataSource %s/%s does not exist on this cluster", ns, dataSourceName)
+			}
+			return nil, err
+		}
+
+		for _, cond := range ds.Status.Conditions {
+			if cond.Type == cdiv1beta1.DataSourceReady && cond.Status != corev1.ConditionTrue {
+				return nil, fmt.Errorf("golden image not ready: DataSource %s/%s is not Ready: %s: %s", ns, dataSourceName, cond.Reason, cond.Message)
+			}
+		}
+
+		if pvcSource := ds.Status.Source.PVC; pvcSource != nil {
+			var pvc corev1.PersistentVolumeClaim
+			if err := c.Client().Resources(pvcSource.Namespace).Get(ctx, pvcSource.Name, pvcSource.Namespace, &pvc); err != nil {
+				return nil, fmt.Errorf("golden image missing: PVC %s/%s of DataSource %s/%s: %v", pvcSource.Namespace, pvcSource.Name, ns, dataSourceName, err)
+			}
+		} else if ds.Status.Source.Snapshot == nil {
+			return nil, fmt.Errorf("golden image missing: DataSource %s/%s does not point at a PVC or a VolumeSnapshot", ns, dataSourceName)
+		}
+
+		cronName, managed := ds.Labels[labelDataImportCron]
+		if !managed || maxAge == 0 {
+			return &ds, nil
+		}
+		if err := checkDataImportCronFresh(ctx, c, cronName, ns, maxAge); err != nil {
+			return nil, fmt.Errorf("golden image stale: DataSource %s/%s: %v", ns, dataSourceName, err)
+		}
+		return &ds, nil
+	}
+}
+
+// GetGoldenImagePVC returns the PVC the DataImportCron imported last, i.e. the current golden image
+func GetGoldenImagePVC(dataImportCronName, ns string) func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.DataVolumeSourcePVC, error) {
+	return func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.DataVolumeSourcePVC, error) {
+		var cron cdiv1beta1.DataImportCron
+		if err := c.Client().Resources(ns).Get(ctx, dataImportCronName, ns, &cron); err != nil {
+			return nil, err
+		}
+		if cron.Status.LastImportedPVC == nil {
+			return nil, fmt.Errorf("DataImportCron %s/%s did not import any image yet", ns, dataImportCronName)
+		}
+		return cron.Status.LastImportedPVC, nil
+	}
+}
+
+func checkDataImportCronFresh(ctx context.Context, c *envconf.Config, cronName, ns string, maxAge time.Duration) error {
+	var cron cdiv1beta1.DataImportCron
+	if err := c.Client().Resources(ns).Get(ctx, cronName, ns, &cron); err != nil {
+		return fmt.Errorf("failed to get DataImportCron %s: %v", cronName, err)
+	}
+
+	for _, cond := range cron.Status.Conditions {
+		if cond.Type == cdiv1beta1.DataImportCronUpToDate && cond.Status != corev1.ConditionTrue {
+			return fmt.Errorf("DataImportCron %s is not UpToDate: %s: %s", cronName, cond.Reason, cond.Message)
+		}
+	}
+
+	lastImport := cron.Status.LastImportTimestamp
+	if lastImport == nil {
+		return fmt.Errorf("DataImportCron %s did not import any image yet", cronName)
+	}
+	if age := time.Since(lastImport.Time); age > maxAge {
+		return fmt.Errorf("DataImportCron %s last imported %s ago, more than %s", cronName, age.Round(time.Minute), maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package datavolume
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	corev1 "k8s.io/api/core/v1"
+	storagev1 "k8s.io/api/storage/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	annDefaultStorageClass string = "storageclass.kubernetes.io/is-default-class"
+)
+
+// StorageProfiles are cluster scoped and named after their storage class. An empty storageClass
+// resolves to the default storage class of the cluster
+func GetStorageProfile(storageClass string) func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.StorageProfile, error) {
+	return func(ctx context.Context, c *envconf.Config) (*cdiv1beta1.StorageProfile, error) {
+		if storageClass == "" {
+			sc, err := getDefaultStorageClass(ctx, c)
+			if err != nil {
+				return nil, err
+			}
+			storageClass = sc
+		}
+
+		var profile cdiv1beta1.StorageProfile
+		if err := c.Client().Resources().Get(ctx, storageClass, "", &profile); err != nil {
+			return nil, fmt.Errorf("failed to get StorageProfile %s: %v", storageClass, err)
+		}
+		return &profile, nil
+	}
+}
+
+// ResolveStorage fills the access modes and volume mode of d from the first claim property set of the
+// storage class's StorageProfile which supports all the access modes and the volume mode d asks for.
+// Tests then only state what they need, e.g. ReadWriteMany for live migration, and not how the
+// storage class provides it
+func ResolveStorage(d DataVolumeData) func(ctx context.Context, c *envconf.Config) (DataVolumeData, error) {
+	return func(ctx context.Context, c *envconf.Config) (DataVolumeData, error) {
+		profile, err := GetStorageProfile(d.StorageClassName)(ctx, c)
+		if err != nil {
+			return d, err
+		}
+
+		for _, set := range profile.Status.ClaimPropertySets {
+			if !claimPropertySetSupports(set, d.PVAccessModes, d.PVMode) {
+				continue
+			}
+			if len(d.PVAccessModes) == 0 {
+				d.PVAccessModes = set.AccessModes
+			}
+			if set.VolumeMode != nil {
+				d.PVMode = *set.VolumeMode
+			}
+			if d.StorageClassName == "" && profile.Status.StorageClass != nil {
+				d.StorageClassName = *profile.Status.StorageClass
+			}
+			return d, nil
+		}
+		return d, fmt.Errorf("StorageProfile %s has no claim property set supporting access modes %v and volume mode %q, available: %s",
+			profile.Name, d.PVAccessModes, d.PVMode, formatClaimPropertySets(profile.Status.ClaimPropertySets))
+	}
+}
+
+func claimPropertySetSupports(set cdiv1beta1.ClaimPropertySet, accessModes []corev1.PersistentVolumeAccessMode, volumeMode corev1.PersistentVolumeMode) bool {
+	for _, mode := range accessModes {
+		if !slices.Contains(set.AccessModes, mode) {
+			return false
+		}
+	}
+	// Kubernetes implies Filesystem when the volume mode is not set
+	setVolumeMode := corev1.PersistentVolumeFilesystem
+	if set.VolumeMode != nil {
+		setVolumeMode = *set.VolumeMode
+	}
+	return volumeMode == "" || volumeMode == setVolumeMode
+}
+
+func formatClaimPropertySets(sets []cdiv1beta1.ClaimPropertySet) string {
+	var formatted []string
+	for _, set := range sets {
+		volumeMode := corev1.PersistentVolumeFilesystem
+		if set.VolumeMode != nil {
+			volumeMode = *set.VolumeMode
+		}
+		formatted = append(formatted, fmt.Sprintf("%v/%s", set.AccessModes, volumeMode))
+	}
+	return fmt.Sprintf("%v", formatted)
+}
+
+func getDefaultStorageClass(ctx context.Context, c *envconf.Config) (string, error) {
+	var scList storagev1.StorageClassList
+	if err := c.Client().Resources().List(ctx, &scList); err != nil {
+		return "", err
+	}
+	for _, sc := range scList.Items {
+		if sc.Annotations[annDefaultStorageClass] == "true" {
+			return sc.Name, nil
+		}
+	}
+	return "", fmt.Errorf("no default storage class found")
+}
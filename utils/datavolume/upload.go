@@ -0,0 +1,100 @@
+package datavolume
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	uploadv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/upload/v1beta1"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Synchronous upload, the upload proxy answers once the image was written and converted
+	UploadProxyPath string = "/v1beta1/upload"
+)
+
+// RequestUploadToken returns the token the upload proxy accepts for uploads to the PVC of a DataVolume,
+// which is named after it. UploadTokenRequests are not stored, the token comes back in the create response.
+// Requires upload.cdi.kubevirt.io to be added to the client's scheme
+func RequestUploadToken(pvcName, ns string) func(ctx context.Context, c *envconf.Config) (string, error) {
+	return func(ctx context.Context, c *envconf.Config) (string, error) {
+		req := uploadv1beta1.UploadTokenRequest{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pvcName,
+				Namespace: ns,
+			},
+			Spec: uploadv1beta1.UploadTokenRequestSpec{
+				PvcName: pvcName,
+			},
+		}
+		if err := c.Client().Resources(ns).Create(ctx, &req); err != nil {
+			return "", fmt.Errorf("failed to request an upload token for %s: %v", pvcName, err)
+		}
+		if req.Status.Token == "" {
+			return "", fmt.Errorf("no upload token returned for %s", pvcName)
+		}
+		return req.Status.Token, nil
+	}
+}
+
+// UploadImage streams the image to the upload proxy at uploadProxyURL, e.g. https://cdi-uploadproxy.example.com.
+// The client carries the TLS configuration of the proxy, which lets tests point it at a local stand-in
+func UploadImage(ctx context.Context, client *http.Client, uploadProxyURL, token string, image io.Reader, size int64) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(uploadProxyURL, "/")+UploadProxyPath, image)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.ContentLength = size
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("upload to %s failed: %v", uploadProxyURL, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("upload to %s failed: %s: %s", uploadProxyURL, resp.Status, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// UploadToDataVolume waits for the upload DataVolume to become UploadReady, requests a token and uploads the image.
+// It does not wait for the DataVolume to Succeed, see WaitForDataVolume
+func UploadToDataVolume(dvName, ns, uploadProxyURL string, client *http.Client, image io.Reader, size int64, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		dv := &cdiv1beta1.DataVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      dvName,
+				Namespace: ns,
+			},
+		}
+		// The upload server pod has to be running before the proxy accepts the upload
+		if err := wait.For(conditions.New(c.Client().Resources(ns)).ResourceMatch(dv, vmconditions.DataVolumePhaseMatch(cdiv1beta1.UploadReady, cdiv1beta1.Failed)),
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval)); err != nil {
+			return fmt.Errorf("DataVolume %s did not become UploadReady: %v", dvName, err)
+		}
+		if vmconditions.DataVolumeFailed()(dv) {
+			return fmt.Errorf("DataVolume %s failed before the upload: %s", dvName, runningConditionMessage(dv))
+		}
+
+		token, err := RequestUploadToken(dvName, ns)(ctx, c)
+		if err != nil {
+			return err
+		}
+		return UploadImage(ctx, client, uploadProxyURL, token, image, size)
+	}
+}
@@ -0,0 +1,179 @@
+package datavolume
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// CDI records the name of the pod populating the PVC on the PVC itself
+	annImportPod string = "cdi.kubevirt.io/storage.import.importPodName"
+	annUploadPod string = "cdi.kubevirt.io/storage.uploadPodName"
+	// Host assisted clones read the source PVC from a pod in the source namespace
+	annCloneSourcePod string = "cdi.kubevirt.io/storage.sourceClonePodName"
+	// Label of every pod CDI creates
+	cdiPodSelector string = "app=containerized-data-importer"
+	// Enough to see why the importer failed without flooding the test output
+	podLogTailLines int64 = 50
+)
+
+// WaitForDataVolume waits until the DataVolume Succeeded, logging through logf, e.g. t.Logf, whenever
+// its phase, progress or restart count changes. It returns as soon as the DataVolume Failed rather than
+// waiting for the timeout, and on failure or timeout the error carries the logs of the populating pod.
+// With firstConsumerDone, WaitForFirstConsumer counts as done too, for DataVolumes no pod consumes yet on
+// storage classes which bind volumes on first consumer, e.g. a blank disk to hotplug
+func WaitForDataVolume(name, ns string, firstConsumerDone bool, timeout, interval time.Duration, logf func(format string, args ...interface{})) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		var dv cdiv1beta1.DataVolume
+		var last cdiv1beta1.DataVolumeStatus
+		succeeded := vmconditions.DataVolumeSucceeded()
+		if firstConsumerDone {
+			succeeded = vmconditions.DataVolumePhaseMatch(cdiv1beta1.Succeeded, cdiv1beta1.WaitForFirstConsumer)
+		}
+		failed := vmconditions.DataVolumeFailed()
+
+		err := wait.For(func(ctx context.Context) (bool, error) {
+			if err := c.Client().Resources(ns).Get(ctx, name, ns, &dv); err != nil {
+				if apierrors.IsNotFound(err) {
+					return false, nil
+				}
+				return false, err
+			}
+
+			status := dv.Status
+			if status.Phase != last.Phase || status.Progress != last.Progress || status.RestartCount != last.RestartCount {
+				logf("DataVolume %s: phase %s, progress %s, restarts %d", name, status.Phase, status.Progress, status.RestartCount)
+				last = status
+			}
+
+			if failed(&dv) {
+				return false, fmt.Errorf("DataVolume %s failed: %s", name, runningConditionMessage(&dv))
+			}
+			return succeeded(&dv), nil
+		},
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval))
+		if err == nil {
+			return nil
+		}
+
+		logs, logErr := GetPopulatorPodLogs(name, ns)(ctx, c)
+		if logErr != nil {
+			return fmt.Errorf("%v (populator pod logs unavailable: %v)", err, logErr)
+		}
+		return fmt.Errorf("%v\npopulator pod logs:\n%s", err, logs)
+	}
+}
+
+// GetPopulatorPodLogs returns the tail of the logs of the importer, upload or clone source pod populating
+// the DataVolume's PVC. With volume populators CDI populates a prime PVC, prime-<PVC UID>, and records the
+// pod there rather than on the PVC. A pod recorded on neither is looked up by the CDI labels
+func GetPopulatorPodLogs(name, ns string) func(ctx context.Context, c *envconf.Config) (string, error) {
+	return func(ctx context.Context, c *envconf.Config) (string, error) {
+		var pvc corev1.PersistentVolumeClaim
+		if err := c.Client().Resources(ns).Get(ctx, name, ns, &pvc); err != nil {
+			return "", err
+		}
+		claims := []*corev1.PersistentVolumeClaim{&pvc}
+		var prime corev1.PersistentVolumeClaim
+		if err := c.Client().Resources(ns).Get(ctx, fmt.Sprintf("prime-%s", pvc.UID), ns, &prime); err == nil {
+			claims = append(claims, &prime)
+		} else if !apierrors.IsNotFound(err) {
+			return "", err
+		}
+
+		// The clone source pod runs next to the source PVC
+		sourceNS := ns
+		var dv cdiv1beta1.DataVolume
+		if err := c.Client().Resources(ns).Get(ctx, name, ns, &dv); err == nil && dv.Spec.Source != nil && dv.Spec.Source.PVC != nil && dv.Spec.Source.PVC.Namespace != "" {
+			sourceNS = dv.Spec.Source.PVC.Namespace
+		}
+
+		podNS, podName := annotatedPopulatorPod(claims, ns, sourceNS)
+		if podName == "" {
+			var err error
+			if podNS, podName, err = labeledPopulatorPod(ctx, c, &pvc, []string{ns, sourceNS}); err != nil {
+				return "", err
+			}
+		}
+		if podName == "" {
+			return "", fmt.Errorf("no populator pod found for PVC %s", name)
+		}
+
+		clientset, err := kubernetes.NewForConfig(c.Client().RESTConfig())
+		if err != nil {
+			return "", err
+		}
+		tailLines := podLogTailLines
+		logs, err := clientset.CoreV1().Pods(podNS).GetLogs(podName, &corev1.PodLogOptions{TailLines: &tailLines}).DoRaw(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to get logs of pod %s/%s: %v", podNS, podName, err)
+		}
+		return string(logs), nil
+	}
+}
+
+// The first pod recorded on the PVCs, and its namespace
+func annotatedPopulatorPod(claims []*corev1.PersistentVolumeClaim, ns, sourceNS string) (string, string) {
+	for _, pvc := range claims {
+		for _, ann := range []string{annImportPod, annUploadPod} {
+			if podName := pvc.Annotations[ann]; podName != "" {
+				return ns, podName
+			}
+		}
+		if podName := pvc.Annotations[annCloneSourcePod]; podName != "" {
+			return sourceNS, podName
+		}
+	}
+	return "", ""
+}
+
+// The most recent CDI pod named after the PVC or its UID, e.g. importer-<PVC name>, importer-prime-<PVC UID>
+// or <PVC UID>-source-pod, and its namespace
+func labeledPopulatorPod(ctx context.Context, c *envconf.Config, pvc *corev1.PersistentVolumeClaim, namespaces []string) (string, string, error) {
+	var found *corev1.Pod
+	for i, ns := range namespaces {
+		if i > 0 && ns == namespaces[0] {
+			continue
+		}
+		var pods corev1.PodList
+		if err := c.Client().Resources(ns).List(ctx, &pods, resources.WithLabelSelector(cdiPodSelector)); err != nil {
+			return "", "", fmt.Errorf("failed to list CDI pods in namespace %s: %v", ns, err)
+		}
+		for j := range pods.Items {
+			pod := &pods.Items[j]
+			if !strings.Contains(pod.Name, pvc.Name) && !strings.Contains(pod.Name, string(pvc.UID)) {
+				continue
+			}
+			if found == nil || pod.CreationTimestamp.After(found.CreationTimestamp.Time) {
+				found = pod
+			}
+		}
+	}
+	if found == nil {
+		return "", "", nil
+	}
+	return found.Namespace, found.Name, nil
+}
+
+// The Running condition explains why the populating pod stopped
+func runningConditionMessage(dv *cdiv1beta1.DataVolume) string {
+	for _, cond := range dv.Status.Conditions {
+		if cond.Type == cdiv1beta1.DataVolumeRunning {
+			return fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+		}
+	}
+	return "no Running condition reported"
+}
@@ -0,0 +1,413 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/apimachinery/pkg/util/version"
+	"sigs.k8s.io/yaml"
+)
+
+// NodeBaseline declares the system components expected on the nodes of each pool
+type NodeBaseline struct {
+	Pools []PoolBaseline
+}
+
+// PoolBaseline holds the expectations for a pool of nodes. An expectation is either the exact value, e.g. "amd64",
+// or for the kernel, kubelet and container runtime versions comma separated version constraints, e.g.
+// ">=v1.29.0,<v1.30". Empty ones are not checked
+type PoolBaseline struct {
+	Name string
+	// Label selector of the nodes of the pool, e.g. node-role.kubernetes.io/worker. A node belongs to the first
+	// pool it matches, an empty selector matches every node
+	Selector                string
+	KernelVersion           string
+	OSImage                 string
+	KubeletVersion          string
+	ContainerRuntimeVersion string
+	OperatingSystem         string
+	Architecture            string
+	// Thresholds on the node's capacity and allocatable resources, a quantity optionally prefixed by
+	// >=, <=, >, < or =, a minimum when it is not, e.g. hugepages-1Gi: 16Gi or devices.kubevirt.io/kvm: ">=1"
+	Capacity    map[corev1.ResourceName]string
+	Allocatable map[corev1.ResourceName]string
+	// Labels the nodes must carry, an empty value only requires the key, e.g. topology.kubernetes.io/zone: ""
+	// or cpumanager: "true", set by KubeVirt on nodes with the static CPU manager policy
+	Labels map[string]string
+	// Taints the nodes must carry, an empty value or effect matches any
+	Taints []corev1.Taint
+}
+
+// Drift is a system component of a node which differs from the baseline or from the other nodes of its pool
+type Drift struct {
+	Node     string
+	Pool     string
+	Field    string
+	Value    string
+	Expected string
+}
+
+func (d Drift) Error() string {
+	return fmt.Sprintf("node %s of pool %s: %s is %q, expected %s", d.Node, d.Pool, d.Field, d.Value, d.Expected)
+}
+
+// PoolReport is the conformance of the nodes of a pool
+type PoolReport struct {
+	Pool  string
+	Nodes []string
+	// Value of each field on most nodes of the pool, a field without a single most common value is left out
+	Majority map[string]string
+	// Nodes differing from the majority of the pool
+	Outliers []Drift
+	// Nodes not meeting the baseline
+	Violations []Drift
+}
+
+// Pool name of the nodes matching no pool of the baseline
+const UnassignedPool string = "unassigned"
+
+// Compares two versions of a field, and fails when one of them does not parse
+type versionComparator func(a, b string) (int, error)
+
+// System components in the order they are reported, the ones without a comparator only take exact values
+var baselineFields = []struct {
+	name     string
+	node     func(info *corev1.NodeSystemInfo) string
+	baseline func(p *PoolBaseline) string
+	compare  versionComparator
+}{
+	{"KernelVersion", func(i *corev1.NodeSystemInfo) string { return i.KernelVersion }, func(p *PoolBaseline) string { return p.KernelVersion }, compareKernelVersions},
+	{"OSImage", func(i *corev1.NodeSystemInfo) string { return i.OSImage }, func(p *PoolBaseline) string { return p.OSImage }, nil},
+	{"KubeletVersion", func(i *corev1.NodeSystemInfo) string { return i.KubeletVersion }, func(p *PoolBaseline) string { return p.KubeletVersion }, compareKubeletVersions},
+	{"ContainerRuntimeVersion", func(i *corev1.NodeSystemInfo) string { return i.ContainerRuntimeVersion }, func(p *PoolBaseline) string { return p.ContainerRuntimeVersion }, compareRuntimeVersions},
+	{"OperatingSystem", func(i *corev1.NodeSystemInfo) string { return i.OperatingSystem }, func(p *PoolBaseline) string { return p.OperatingSystem }, nil},
+	{"Architecture", func(i *corev1.NodeSystemInfo) string { return i.Architecture }, func(p *PoolBaseline) string { return p.Architecture }, nil},
+}
+
+// LoadNodeBaseline reads a baseline from a YAML file. Keys are the Go field names matched case insensitively,
+// e.g. kubeletVersion, and unknown keys are rejected
+func LoadNodeBaseline(path string) (*NodeBaseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read node baseline %s: %v", path, err)
+	}
+	var b NodeBaseline
+	if err := yaml.UnmarshalStrict(data, &b); err != nil {
+		return nil, fmt.Errorf("failed to decode node baseline %s: %v", path, err)
+	}
+	return &b, nil
+}
+
+func (b NodeBaseline) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+	poolsPath := field.NewPath("NodeBaseline").Child("Pools")
+
+	names := map[string]bool{}
+	for i := range b.Pools {
+		pool := &b.Pools[i]
+		poolPath := poolsPath.Index(i)
+		if pool.Name == "" {
+			allErrs = append(allErrs, field.Required(poolPath.Child("Name"), ""))
+		} else if names[pool.Name] || pool.Name == UnassignedPool {
+			allErrs = append(allErrs, field.Duplicate(poolPath.Child("Name"), pool.Name))
+		}
+		names[pool.Name] = true
+		if _, err := labels.Parse(pool.Selector); err != nil {
+			allErrs = append(allErrs, field.Invalid(poolPath.Child("Selector"), pool.Selector, err.Error()))
+		}
+		for _, f := range baselineFields {
+			if _, err := parseExpectation(f.baseline(pool), f.compare); err != nil {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child(f.name), f.baseline(pool), err.Error()))
+			}
+		}
+		allErrs = append(allErrs, validateResources(pool, poolPath)...)
+	}
+	return allErrs
+}
+
+// Check groups the nodes by pool, and reports for each pool the nodes not meeting the baseline and the ones
+// differing from the majority of the pool. A pool is compared with its own majority only, so one odd node does
+// not make the others look wrong. Nodes matching no pool are reported in UnassignedPool, for drift only
+func (b NodeBaseline) Check(nodes []corev1.Node) []PoolReport {
+	poolNodes := map[string][]*corev1.Node{}
+	for i := range nodes {
+		name := b.poolOf(&nodes[i])
+		poolNodes[name] = append(poolNodes[name], &nodes[i])
+	}
+
+	var reports []PoolReport
+	for i := range b.Pools {
+		if members, ok := poolNodes[b.Pools[i].Name]; ok {
+			reports = append(reports, checkPool(b.Pools[i].Name, &b.Pools[i], members))
+		}
+	}
+	if members, ok := poolNodes[UnassignedPool]; ok {
+		reports = append(reports, checkPool(UnassignedPool, nil, members))
+	}
+	return reports
+}
+
+func (b NodeBaseline) poolOf(node *corev1.Node) string {
+	for _, pool := range b.Pools {
+		selector, err := labels.Parse(pool.Selector)
+		if err == nil && selector.Matches(labels.Set(node.Labels)) {
+			return pool.Name
+		}
+	}
+	return UnassignedPool
+}
+
+func checkPool(name string, baseline *PoolBaseline, nodes []*corev1.Node) PoolReport {
+	report := PoolReport{Pool: name, Majority: map[string]string{}}
+	for _, node := range nodes {
+		report.Nodes = append(report.Nodes, node.Name)
+	}
+
+	for _, f := range baselineFields {
+		counts := map[string]int{}
+		for _, node := range nodes {
+			counts[f.node(&node.Status.NodeInfo)]++
+		}
+		if majority, ok := majorityOf(counts); ok {
+			report.Majority[f.name] = majority
+			for _, node := range nodes {
+				if value := f.node(&node.Status.NodeInfo); value != majority {
+					report.Outliers = append(report.Outliers, Drift{Node: node.Name, Pool: name, Field: f.name, Value: value, Expected: fmt.Sprintf("%q like %d of %d nodes", majority, counts[majority], len(nodes))})
+				}
+			}
+		}
+
+		if baseline == nil {
+			continue
+		}
+		expectation, _ := parseExpectation(f.baseline(baseline), f.compare)
+		if expectation == nil {
+			continue
+		}
+		for _, node := range nodes {
+			if value := f.node(&node.Status.NodeInfo); !expectation.matches(value) {
+				report.Violations = append(report.Violations, Drift{Node: node.Name, Pool: name, Field: f.name, Value: value, Expected: expectation.String()})
+			}
+		}
+	}
+	return report
+}
+
+// The single most common value, a tie has none
+func majorityOf(counts map[string]int) (string, bool) {
+	var majority string
+	var best, bestCount int
+	for value, count := range counts {
+		if count > best {
+			majority, best, bestCount = value, count, 1
+		} else if count == best {
+			bestCount++
+		}
+	}
+	return majority, bestCount == 1
+}
+
+type constraint struct {
+	op      string
+	version string
+}
+
+// Exact value, or version constraints which must all be met
+type expectation struct {
+	exact       string
+	constraints []constraint
+	compare     versionComparator
+}
+
+// Longest operators first, so ">=" is not read as ">"
+var constraintOperators = []string{">=", "<=", "!=", ">", "<", "="}
+
+func parseExpectation(s string, compare versionComparator) (*expectation, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, nil
+	}
+	if !strings.ContainsAny(s[:1], "<>=!") {
+		return &expectation{exact: s}, nil
+	}
+	if compare == nil {
+		return nil, fmt.Errorf("version constraints are not supported, expected an exact value")
+	}
+
+	e := &expectation{compare: compare}
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		var c constraint
+		for _, op := range constraintOperators {
+			if strings.HasPrefix(item, op) {
+				c = constraint{op: op, version: strings.TrimSpace(strings.TrimPrefix(item, op))}
+				break
+			}
+		}
+		if c.op == "" || c.version == "" {
+			return nil, fmt.Errorf("version constraint %q not in the form <operator><version>, e.g. >=v1.29.0", item)
+		}
+		if _, err := compare(c.version, c.version); err != nil {
+			return nil, fmt.Errorf("version constraint %q: %v", item, err)
+		}
+		e.constraints = append(e.constraints, c)
+	}
+	return e, nil
+}
+
+func (e *expectation) matches(value string) bool {
+	if e.constraints == nil {
+		return value == e.exact
+	}
+	for _, c := range e.constraints {
+		// A value which does not parse meets no constraint
+		cmp, err := e.compare(value, c.version)
+		if err != nil {
+			return false
+		}
+		var ok bool
+		switch c.op {
+		case ">=":
+			ok = cmp >= 0
+		case "<=":
+			ok = cmp <= 0
+		case "!=":
+			ok = cmp != 0
+		case ">":
+			ok = cmp > 0
+		case "<":
+			ok = cmp < 0
+		case "=":
+			ok = cmp == 0
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *expectation) String() string {
+	if e.constraints == nil {
+		return fmt.Sprintf("%q", e.exact)
+	}
+	items := make([]string, 0, len(e.constraints))
+	for _, c := range e.constraints {
+		items = append(items, c.op+c.version)
+	}
+	return strings.Join(items, ",")
+}
+
+// Kubelet versions are semantic, so a pre-release is before its release, e.g. v1.30.0-rc.1 before v1.30.0, and
+// build metadata is ignored, e.g. the +29c6d0b of v1.29.5+29c6d0b. Constraints may leave the patch out, e.g. <v1.30
+func compareKubeletVersions(a, b string) (int, error) {
+	return compareParsedVersions(a, b, func(v string) (*version.Version, error) {
+		if parsed, err := version.ParseSemantic(v); err == nil {
+			return parsed, nil
+		}
+		return version.ParseGeneric(v)
+	})
+}
+
+// Container runtime versions are prefixed by the runtime, e.g. cri-o://1.29.5-5.rhaos4.16.git7c8b6a9.el9. Only their
+// numeric components are compared, the distribution suffix is not a pre-release
+func compareRuntimeVersions(a, b string) (int, error) {
+	return compareParsedVersions(a, b, func(v string) (*version.Version, error) {
+		if _, after, ok := strings.Cut(v, "://"); ok {
+			v = after
+		}
+		return version.ParseGeneric(v)
+	})
+}
+
+func compareParsedVersions(a, b string, parse func(v string) (*version.Version, error)) (int, error) {
+	va, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+	switch {
+	case va.LessThan(vb):
+		return -1, nil
+	case vb.LessThan(va):
+		return 1, nil
+	}
+	return 0, nil
+}
+
+// Distribution kernel versions follow no scheme, e.g. 5.14.0-427.22.1.el9_4.x86_64, so they are compared component
+// by component, numbers as numbers so 427.9 is before 427.10. Components past the end of the shorter version are not
+// compared, so 5.14.0-427.22.1.el9_4.x86_64 meets a constraint on 5.14.0-427. Versions must start with a number
+func compareKernelVersions(a, b string) (int, error) {
+	ta, tb := kernelVersionComponents(a), kernelVersionComponents(b)
+	if len(ta) == 0 || !unicode.IsDigit(rune(ta[0][0])) {
+		return 0, fmt.Errorf("could not parse %q as a kernel version", a)
+	}
+	if len(tb) == 0 || !unicode.IsDigit(rune(tb[0][0])) {
+		return 0, fmt.Errorf("could not parse %q as a kernel version", b)
+	}
+	for i := 0; i < len(ta) && i < len(tb); i++ {
+		na, errA := strconv.Atoi(ta[i])
+		nb, errB := strconv.Atoi(tb[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na != nb {
+				if na < nb {
+					return -1, nil
+				}
+				return 1, nil
+			}
+		// A number is after a word, e.g. the rebuilt 5.14.0-427.22.1.el9_4 after 5.14.0-427.el9_4
+		case errA == nil:
+			return 1, nil
+		case errB == nil:
+			return -1, nil
+		default:
+			if c := strings.Compare(ta[i], tb[i]); c != 0 {
+				return c, nil
+			}
+		}
+	}
+	return 0, nil
+}
+
+// Runs of digits and of letters
+func kernelVersionComponents(v string) []string {
+	v = strings.TrimSpace(v)
+
+	var components []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			components = append(components, string(current))
+			current = nil
+		}
+	}
+	for _, r := range v {
+		switch {
+		case unicode.IsDigit(r):
+			if len(current) > 0 && !unicode.IsDigit(current[0]) {
+				flush()
+			}
+			current = append(current, r)
+		case unicode.IsLetter(r):
+			if len(current) > 0 && unicode.IsDigit(current[0]) {
+				flush()
+			}
+			current = append(current, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+	return components
+}
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func workerNode(name, kernel, kubelet, runtime string) corev1.Node {
+	return corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"node-role.kubernetes.io/worker": ""}},
+		Status: corev1.NodeStatus{NodeInfo: corev1.NodeSystemInfo{
+			KernelVersion:           kernel,
+			OSImage:                 "Red Hat Enterprise Linux CoreOS 416.94.202406172220-0",
+			KubeletVersion:          kubelet,
+			ContainerRuntimeVersion: runtime,
+			OperatingSystem:         "linux",
+			Architecture:            "amd64",
+		}},
+	}
+}
+
+func TestMajorityOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		counts map[string]int
+		want   string
+		wantOK bool
+	}{
+		{name: "no values", counts: map[string]int{}},
+		{name: "single value", counts: map[string]int{"amd64": 3}, want: "amd64", wantOK: true},
+		{name: "most common", counts: map[string]int{"v1.29.5": 2, "v1.29.4": 1}, want: "v1.29.5", wantOK: true},
+		{name: "tie", counts: map[string]int{"v1.29.5": 2, "v1.29.4": 2}},
+		{name: "tie below the most common", counts: map[string]int{"a": 3, "b": 1, "c": 1}, want: "a", wantOK: true},
+		{name: "tie of single nodes", counts: map[string]int{"a": 1, "b": 1}},
+	}
+
+	feat := features.New("Node baseline majority").
+		WithLabel("type", "Node").
+		Assess("Test finding the most common value of a pool", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, ok := majorityOf(tt.counts)
+					if ok != tt.wantOK || (ok && got != tt.want) {
+						t.Errorf("got %q, %t, want %q, %t", got, ok, tt.want, tt.wantOK)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestNodeBaselineCheck(t *testing.T) {
+	const (
+		kernel  = "5.14.0-427.22.1.el9_4.x86_64"
+		kubelet = "v1.29.5+29c6d0b"
+		runtime = "cri-o://1.29.5-5.rhaos4.16.git7c8b6a9.el9"
+	)
+	baseline := NodeBaseline{Pools: []PoolBaseline{{
+		Name:                    "worker",
+		Selector:                "node-role.kubernetes.io/worker",
+		KernelVersion:           ">=5.14.0-427",
+		KubeletVersion:          ">=v1.29.0,<v1.30",
+		ContainerRuntimeVersion: ">=cri-o://1.29.5,<cri-o://1.30",
+		Architecture:            "amd64",
+	}}}
+
+	tests := []struct {
+		name           string
+		nodes          []corev1.Node
+		wantOutliers   []string
+		wantViolations []string
+		// Fields without a majority
+		wantNoMajority []string
+	}{
+		{
+			name:  "conforming pool",
+			nodes: []corev1.Node{workerNode("worker-0", kernel, kubelet, runtime), workerNode("worker-1", kernel, kubelet, runtime), workerNode("worker-2", kernel, kubelet, runtime)},
+		},
+		{
+			name:           "odd first node",
+			nodes:          []corev1.Node{workerNode("worker-0", kernel, "v1.28.9", runtime), workerNode("worker-1", kernel, kubelet, runtime), workerNode("worker-2", kernel, kubelet, runtime)},
+			wantOutliers:   []string{"worker-0 KubeletVersion"},
+			wantViolations: []string{"worker-0 KubeletVersion"},
+		},
+		{
+			name:           "tie has no majority",
+			nodes:          []corev1.Node{workerNode("worker-0", kernel, "v1.29.4", runtime), workerNode("worker-1", kernel, kubelet, runtime)},
+			wantNoMajority: []string{"KubeletVersion"},
+		},
+		{
+			name:           "pre-release kubelet",
+			nodes:          []corev1.Node{workerNode("worker-0", kernel, "v1.30.0-rc.1", runtime)},
+			wantViolations: []string{"worker-0 KubeletVersion"},
+		},
+		{
+			name:           "older kernel build and runtime",
+			nodes:          []corev1.Node{workerNode("worker-0", "5.14.0-362.24.1.el9_3.x86_64", kubelet, "cri-o://1.29.4-3.rhaos4.16.el9")},
+			wantViolations: []string{"worker-0 KernelVersion", "worker-0 ContainerRuntimeVersion"},
+		},
+		{
+			name:           "versions not reported",
+			nodes:          []corev1.Node{workerNode("worker-0", "", "", runtime)},
+			wantViolations: []string{"worker-0 KernelVersion", "worker-0 KubeletVersion"},
+		},
+	}
+
+	feat := features.New("Node baseline check").
+		WithLabel("type", "Node").
+		Assess("Test checking pools against the baseline and their majority", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if errs := baseline.Validate(); len(errs) > 0 {
+				t.Fatal(errs.ToAggregate())
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					reports := baseline.Check(tt.nodes)
+					if len(reports) != 1 || reports[0].Pool != "worker" {
+						t.Fatalf("got reports %v, want a single one of pool worker", reports)
+					}
+					report := reports[0]
+					assertDrift(t, "outliers", report.Outliers, tt.wantOutliers)
+					assertDrift(t, "violations", report.Violations, tt.wantViolations)
+					for _, f := range tt.wantNoMajority {
+						if majority, ok := report.Majority[f]; ok {
+							t.Errorf("%s has majority %q, want none", f, majority)
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+// want holds "<node> <field>" of every expected drift, in order
+func assertDrift(t *testing.T, kind string, drift []Drift, want []string) {
+	t.Helper()
+	if len(drift) != len(want) {
+		t.Fatalf("got %d %s, want %d:\n%s", len(drift), kind, len(want), FormatDriftTable(drift))
+	}
+	for i, d := range drift {
+		if got := d.Node + " " + d.Field; got != want[i] {
+			t.Errorf("%s %d is %s, want %s", kind, i, got, want[i])
+		}
+	}
+}
+
+func TestParseExpectation(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		compare versionComparator
+		wantErr bool
+	}{
+		{name: "empty", s: "", compare: compareKubeletVersions},
+		{name: "exact value", s: "amd64"},
+		{name: "constraints", s: ">=v1.29.0, <v1.30", compare: compareKubeletVersions},
+		{name: "runtime constraints", s: ">=cri-o://1.29.0,<cri-o://1.30", compare: compareRuntimeVersions},
+		{name: "kernel constraint", s: "!=5.14.0-427.13.1.el9_4", compare: compareKernelVersions},
+		{name: "missing version", s: ">=", compare: compareKubeletVersions, wantErr: true},
+		{name: "missing operator", s: ">=v1.29.0,v1.30", compare: compareKubeletVersions, wantErr: true},
+		{name: "unknown operator", s: "=>v1.29.0", compare: compareKubeletVersions, wantErr: true},
+		{name: "version does not parse", s: ">=latest", compare: compareKubeletVersions, wantErr: true},
+		{name: "kernel version does not parse", s: ">=latest", compare: compareKernelVersions, wantErr: true},
+		{name: "runtime version does not parse", s: "<cri-o://next", compare: compareRuntimeVersions, wantErr: true},
+		{name: "field without versions", s: ">=linux", wantErr: true},
+	}
+
+	feat := features.New("Node baseline expectations").
+		WithLabel("type", "Node").
+		Assess("Test parsing exact values and version constraints", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					if _, err := parseExpectation(tt.s, tt.compare); (err != nil) != tt.wantErr {
+						t.Errorf("got error %v, want error %t", err, tt.wantErr)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		name    string
+		compare versionComparator
+		a, b    string
+		want    int
+		wantErr bool
+	}{
+		{name: "pre-release before release", compare: compareKubeletVersions, a: "v1.30.0-rc.1", b: "v1.30.0", want: -1},
+		{name: "pre-releases in order", compare: compareKubeletVersions, a: "v1.30.0-rc.2", b: "v1.30.0-rc.1", want: 1},
+		{name: "alpha before rc", compare: compareKubeletVersions, a: "v1.30.0-alpha.3", b: "v1.30.0-rc.1", want: -1},
+		{name: "build metadata ignored", compare: compareKubeletVersions, a: "v1.29.5+29c6d0b", b: "v1.29.5", want: 0},
+		{name: "numeric minor", compare: compareKubeletVersions, a: "v1.9.0", b: "v1.10.0", want: -1},
+		{name: "constraint without patch", compare: compareKubeletVersions, a: "v1.29.5", b: "v1.30", want: -1},
+		{name: "runtime suffix is not a pre-release", compare: compareRuntimeVersions, a: "cri-o://1.29.5-5.rhaos4.16.git7c8b6a9.el9", b: "cri-o://1.29.5", want: 0},
+		{name: "runtime patch", compare: compareRuntimeVersions, a: "containerd://1.7.13", b: "1.7.2", want: 1},
+		{name: "kernel build", compare: compareKernelVersions, a: "5.14.0-427.9.1.el9_4", b: "5.14.0-427.10.1.el9_4", want: -1},
+		{name: "kernel rebuild after release", compare: compareKernelVersions, a: "5.14.0-427.22.1.el9_4", b: "5.14.0-427.el9_4", want: 1},
+		{name: "kernel constraint prefix", compare: compareKernelVersions, a: "5.14.0-427.22.1.el9_4.x86_64", b: "5.14.0-427", want: 0},
+		{name: "empty kubelet version", compare: compareKubeletVersions, a: "", b: "v1.29.0", wantErr: true},
+		{name: "empty runtime version", compare: compareRuntimeVersions, a: "", b: "cri-o://1.29.0", wantErr: true},
+		{name: "empty kernel version", compare: compareKernelVersions, a: "", b: "5.14.0-427", wantErr: true},
+		{name: "kernel version without a number first", compare: compareKernelVersions, a: "el9_4", b: "5.14.0-427", wantErr: true},
+		{name: "garbage kernel constraint", compare: compareKernelVersions, a: "5.14.0-427.22.1.el9_4", b: "latest", wantErr: true},
+	}
+
+	feat := features.New("Node baseline versions").
+		WithLabel("type", "Node").
+		Assess("Test ordering kubelet, container runtime and kernel versions", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := tt.compare(tt.a, tt.b)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+					}
+					if err != nil {
+						return
+					}
+					if got != tt.want {
+						t.Errorf("comparing %s to %s got %d, want %d", tt.a, tt.b, got, tt.want)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,167 @@
+package utils
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ConditionRequirement is the status a node condition must have
+type ConditionRequirement struct {
+	Type   corev1.NodeConditionType
+	Status corev1.ConditionStatus
+	// Only check the condition when the node reports it, e.g. KernelDeadlock which node-problem-detector
+	// only reports on the nodes it runs on
+	Optional bool
+}
+
+// NodeHealthPolicy describes a healthy node
+type NodeHealthPolicy struct {
+	Conditions []ConditionRequirement
+	// Nodes matching the label selector are not checked, e.g. "node-role.kubernetes.io/infra"
+	IgnoreSelector string
+	// Nodes with one of the taints are not checked. An empty value or effect matches any,
+	// e.g. {Key: "node.kubernetes.io/unschedulable"} for cordoned nodes
+	IgnoreTaints []corev1.Taint
+	// A condition whose lastHeartbeatTime is older is stale, as the kubelet or the problem detector
+	// stopped reporting it. Zero does not check heartbeats
+	MaxHeartbeatAge time.Duration
+}
+
+// Violation is a condition of a node not as required by the policy
+type Violation struct {
+	Node      string
+	Condition corev1.NodeConditionType
+	Message   string
+}
+
+func (v Violation) Error() string {
+	return fmt.Sprintf("node %s condition %s %s", v.Node, v.Condition, v.Message)
+}
+
+// DefaultNodeHealthPolicy requires nodes to be Ready without memory, disk or PID pressure and with their network
+// available. Nodes may not report the pressure and network conditions
+func DefaultNodeHealthPolicy() NodeHealthPolicy {
+	return NodeHealthPolicy{
+		Conditions: []ConditionRequirement{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse, Optional: true},
+			{Type: corev1.NodeDiskPressure, Status: corev1.ConditionFalse, Optional: true},
+			{Type: corev1.NodePIDPressure, Status: corev1.ConditionFalse, Optional: true},
+			{Type: corev1.NodeNetworkUnavailable, Status: corev1.ConditionFalse, Optional: true},
+		},
+	}
+}
+
+// ParseConditionRequirements parses a comma separated list of Type=Status, a trailing ? marks the condition
+// Optional, e.g. "ReadonlyFilesystem=False,KernelDeadlock=False?"
+func ParseConditionRequirements(s string) ([]ConditionRequirement, error) {
+	var requirements []ConditionRequirement
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		item, optional := strings.CutSuffix(item, "?")
+		condType, status, ok := strings.Cut(item, "=")
+		if !ok || condType == "" {
+			return nil, fmt.Errorf("node condition %q not in the form Type=Status", item)
+		}
+		requirements = append(requirements, ConditionRequirement{
+			Type:     corev1.NodeConditionType(condType),
+			Status:   corev1.ConditionStatus(status),
+			Optional: optional,
+		})
+	}
+	return requirements, nil
+}
+
+func (p NodeHealthPolicy) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+	policyPath := field.NewPath("NodeHealthPolicy")
+
+	validStatuses := []string{string(corev1.ConditionTrue), string(corev1.ConditionFalse), string(corev1.ConditionUnknown)}
+	for i, req := range p.Conditions {
+		condPath := policyPath.Child("Conditions").Index(i)
+		if req.Type == "" {
+			allErrs = append(allErrs, field.Required(condPath.Child("Type"), ""))
+		}
+		switch req.Status {
+		case corev1.ConditionTrue, corev1.ConditionFalse, corev1.ConditionUnknown:
+		default:
+			allErrs = append(allErrs, field.NotSupported(condPath.Child("Status"), req.Status, validStatuses))
+		}
+	}
+	if _, err := labels.Parse(p.IgnoreSelector); err != nil {
+		allErrs = append(allErrs, field.Invalid(policyPath.Child("IgnoreSelector"), p.IgnoreSelector, err.Error()))
+	}
+	if p.MaxHeartbeatAge < 0 {
+		allErrs = append(allErrs, field.Invalid(policyPath.Child("MaxHeartbeatAge"), p.MaxHeartbeatAge, "must not be negative"))
+	}
+	return allErrs
+}
+
+// Ignores tells whether the node is left out by the policy, and why
+func (p NodeHealthPolicy) Ignores(node *corev1.Node) (bool, string) {
+	if p.IgnoreSelector != "" {
+		if selector, err := labels.Parse(p.IgnoreSelector); err == nil && selector.Matches(labels.Set(node.Labels)) {
+			return true, fmt.Sprintf("labels match %s", p.IgnoreSelector)
+		}
+	}
+	for _, taint := range node.Spec.Taints {
+		for _, ignored := range p.IgnoreTaints {
+			if taint.Key == ignored.Key && (ignored.Value == "" || taint.Value == ignored.Value) && (ignored.Effect == "" || taint.Effect == ignored.Effect) {
+				return true, fmt.Sprintf("tainted %s", taint.ToString())
+			}
+		}
+	}
+	return false, ""
+}
+
+// Check returns every violation of the policy by the node, none when the policy ignores it
+func (p NodeHealthPolicy) Check(node *corev1.Node, now time.Time) []Violation {
+	if ignored, _ := p.Ignores(node); ignored {
+		return nil
+	}
+
+	var violations []Violation
+	for _, req := range p.Conditions {
+		cond := findNodeCondition(node.Status.Conditions, req.Type)
+		if cond == nil {
+			if !req.Optional {
+				violations = append(violations, Violation{Node: node.Name, Condition: req.Type, Message: "not reported"})
+			}
+			continue
+		}
+		if cond.Status != req.Status {
+			violations = append(violations, Violation{
+				Node:      node.Name,
+				Condition: req.Type,
+				Message:   fmt.Sprintf("is %s, want %s: %s: %s", cond.Status, req.Status, cond.Reason, cond.Message),
+			})
+		}
+		if p.MaxHeartbeatAge > 0 && !cond.LastHeartbeatTime.IsZero() {
+			if age := now.Sub(cond.LastHeartbeatTime.Time); age > p.MaxHeartbeatAge {
+				violations = append(violations, Violation{
+					Node:      node.Name,
+					Condition: req.Type,
+					Message:   fmt.Sprintf("is stale, last heartbeat %s ago, more than %s", age.Round(time.Second), p.MaxHeartbeatAge),
+				})
+			}
+		}
+	}
+	return violations
+}
+
+func findNodeCondition(conditions []corev1.NodeCondition, condType corev1.NodeConditionType) *corev1.NodeCondition {
+	for i := range conditions {
+		if conditions[i].Type == condType {
+			return &conditions[i]
+		}
+	}
+	return nil
+}
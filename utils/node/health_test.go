@@ -0,0 +1,164 @@
+package utils
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func testNode(name string, taints []corev1.Taint, conditions ...corev1.NodeCondition) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{"kubernetes.io/hostname": name}},
+		Spec:       corev1.NodeSpec{Taints: taints},
+		Status:     corev1.NodeStatus{Conditions: conditions},
+	}
+}
+
+func nodeCondition(condType corev1.NodeConditionType, status corev1.ConditionStatus, heartbeat time.Time) corev1.NodeCondition {
+	return corev1.NodeCondition{Type: condType, Status: status, LastHeartbeatTime: metav1.NewTime(heartbeat)}
+}
+
+func TestNodeHealthPolicyCheck(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	fresh := now.Add(-10 * time.Second)
+	stale := now.Add(-10 * time.Minute)
+	policy := NodeHealthPolicy{
+		Conditions: []ConditionRequirement{
+			{Type: corev1.NodeReady, Status: corev1.ConditionTrue},
+			{Type: corev1.NodeMemoryPressure, Status: corev1.ConditionFalse, Optional: true},
+			{Type: "KernelDeadlock", Status: corev1.ConditionFalse, Optional: true},
+		},
+		IgnoreSelector:  "node-role.kubernetes.io/infra",
+		IgnoreTaints:    []corev1.Taint{{Key: "node.kubernetes.io/unschedulable"}, {Key: "maintenance", Effect: corev1.TaintEffectNoExecute}},
+		MaxHeartbeatAge: time.Minute,
+	}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		// Conditions of the expected violations, in order
+		want []corev1.NodeConditionType
+	}{
+		{
+			name: "healthy node",
+			node: testNode("worker-0", nil, nodeCondition(corev1.NodeReady, corev1.ConditionTrue, fresh), nodeCondition(corev1.NodeMemoryPressure, corev1.ConditionFalse, fresh)),
+		},
+		{
+			name: "optional conditions not reported",
+			node: testNode("worker-0", nil, nodeCondition(corev1.NodeReady, corev1.ConditionTrue, fresh)),
+		},
+		{
+			name: "required condition not reported",
+			node: testNode("worker-0", nil, nodeCondition(corev1.NodeMemoryPressure, corev1.ConditionFalse, fresh)),
+			want: []corev1.NodeConditionType{corev1.NodeReady},
+		},
+		{
+			name: "ignored by taint",
+			node: testNode("worker-0", []corev1.Taint{{Key: "node.kubernetes.io/unschedulable", Effect: corev1.TaintEffectNoSchedule}}, nodeCondition(corev1.NodeReady, corev1.ConditionFalse, fresh)),
+		},
+		{
+			name: "ignored by taint with the same effect",
+			node: testNode("worker-0", []corev1.Taint{{Key: "maintenance", Value: "kernel", Effect: corev1.TaintEffectNoExecute}}, nodeCondition(corev1.NodeReady, corev1.ConditionFalse, fresh)),
+		},
+		{
+			name: "not ignored by taint with another effect",
+			node: testNode("worker-0", []corev1.Taint{{Key: "maintenance", Effect: corev1.TaintEffectNoSchedule}}, nodeCondition(corev1.NodeReady, corev1.ConditionFalse, fresh)),
+			want: []corev1.NodeConditionType{corev1.NodeReady},
+		},
+		{
+			name: "ignored by label",
+			node: func() *corev1.Node {
+				node := testNode("infra-0", nil, nodeCondition(corev1.NodeReady, corev1.ConditionFalse, fresh))
+				node.Labels["node-role.kubernetes.io/infra"] = ""
+				return node
+			}(),
+		},
+		{
+			name: "stale heartbeat",
+			node: testNode("worker-0", nil, nodeCondition(corev1.NodeReady, corev1.ConditionTrue, stale)),
+			want: []corev1.NodeConditionType{corev1.NodeReady},
+		},
+		{
+			name: "every violation of the node",
+			node: testNode("worker-0", nil,
+				nodeCondition(corev1.NodeReady, corev1.ConditionUnknown, stale),
+				nodeCondition(corev1.NodeMemoryPressure, corev1.ConditionTrue, fresh),
+				nodeCondition("KernelDeadlock", corev1.ConditionTrue, stale)),
+			want: []corev1.NodeConditionType{corev1.NodeReady, corev1.NodeReady, corev1.NodeMemoryPressure, "KernelDeadlock", "KernelDeadlock"},
+		},
+	}
+
+	feat := features.New("Node health policy").
+		WithLabel("type", "Node").
+		Assess("Test checking nodes against the policy", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			if errs := policy.Validate(); len(errs) > 0 {
+				t.Fatal(errs.ToAggregate())
+			}
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					violations := policy.Check(tt.node, now)
+					if len(violations) != len(tt.want) {
+						t.Fatalf("got %d violations, want %d: %v", len(violations), len(tt.want), violations)
+					}
+					for i, condType := range tt.want {
+						if violations[i].Condition != condType || violations[i].Node != tt.node.Name {
+							t.Errorf("violation %d is %v, want one of node %s condition %s", i, violations[i], tt.node.Name, condType)
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestParseConditionRequirements(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    []ConditionRequirement
+		wantErr bool
+	}{
+		{name: "empty", s: ""},
+		{
+			name: "required and optional",
+			s:    "ReadonlyFilesystem=False, KernelDeadlock=False?",
+			want: []ConditionRequirement{
+				{Type: "ReadonlyFilesystem", Status: corev1.ConditionFalse},
+				{Type: "KernelDeadlock", Status: corev1.ConditionFalse, Optional: true},
+			},
+		},
+		{name: "missing status", s: "KernelDeadlock", wantErr: true},
+		{name: "missing type", s: "=False?", wantErr: true},
+	}
+
+	feat := features.New("Node condition requirements").
+		WithLabel("type", "Node").
+		Assess("Test parsing node condition requirements", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					got, err := ParseConditionRequirements(tt.s)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+					}
+					if len(got) != len(tt.want) {
+						t.Fatalf("got %v, want %v", got, tt.want)
+					}
+					for i := range tt.want {
+						if got[i] != tt.want[i] {
+							t.Errorf("requirement %d is %v, want %v", i, got[i], tt.want[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,203 @@
+package utils
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// Threshold operators, a threshold without one is a minimum
+var thresholdOperators = []string{">=", "<=", ">", "<", "="}
+
+type threshold struct {
+	op       string
+	quantity resource.Quantity
+}
+
+func parseThreshold(s string) (threshold, error) {
+	s = strings.TrimSpace(s)
+	t := threshold{op: ">="}
+	for _, op := range thresholdOperators {
+		if strings.HasPrefix(s, op) {
+			t.op = op
+			s = strings.TrimSpace(strings.TrimPrefix(s, op))
+			break
+		}
+	}
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return t, err
+	}
+	t.quantity = q
+	return t, nil
+}
+
+func (t threshold) matches(q resource.Quantity) bool {
+	cmp := q.Cmp(t.quantity)
+	switch t.op {
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case "<":
+		return cmp < 0
+	case "=":
+		return cmp == 0
+	default:
+		return cmp >= 0
+	}
+}
+
+func (t threshold) String() string {
+	return t.op + t.quantity.String()
+}
+
+func validateResources(pool *PoolBaseline, poolPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for _, list := range []struct {
+		name       string
+		thresholds map[corev1.ResourceName]string
+	}{{"Capacity", pool.Capacity}, {"Allocatable", pool.Allocatable}} {
+		for name, value := range list.thresholds {
+			if _, err := parseThreshold(value); err != nil {
+				allErrs = append(allErrs, field.Invalid(poolPath.Child(list.name).Key(string(name)), value, err.Error()))
+			}
+		}
+	}
+
+	validEffects := []string{string(corev1.TaintEffectNoSchedule), string(corev1.TaintEffectPreferNoSchedule), string(corev1.TaintEffectNoExecute)}
+	for i, taint := range pool.Taints {
+		taintPath := poolPath.Child("Taints").Index(i)
+		if taint.Key == "" {
+			allErrs = append(allErrs, field.Required(taintPath.Child("Key"), ""))
+		}
+		switch taint.Effect {
+		case "", corev1.TaintEffectNoSchedule, corev1.TaintEffectPreferNoSchedule, corev1.TaintEffectNoExecute:
+		default:
+			allErrs = append(allErrs, field.NotSupported(taintPath.Child("Effect"), taint.Effect, validEffects))
+		}
+	}
+	return allErrs
+}
+
+// CheckResources returns, for every node of a pool, the capacity and allocatable resources outside the
+// thresholds of the pool and the required labels and taints it misses. Nodes matching no pool are not checked
+func (b NodeBaseline) CheckResources(nodes []corev1.Node) []Drift {
+	var mismatches []Drift
+	for i := range nodes {
+		node := &nodes[i]
+		poolName := b.poolOf(node)
+		for j := range b.Pools {
+			if b.Pools[j].Name == poolName {
+				mismatches = append(mismatches, checkNodeResources(node, &b.Pools[j])...)
+				break
+			}
+		}
+	}
+	return mismatches
+}
+
+func checkNodeResources(node *corev1.Node, pool *PoolBaseline) []Drift {
+	var mismatches []Drift
+	mismatch := func(fieldName, value, expected string) {
+		mismatches = append(mismatches, Drift{Node: node.Name, Pool: pool.Name, Field: fieldName, Value: value, Expected: expected})
+	}
+
+	for _, list := range []struct {
+		name       string
+		thresholds map[corev1.ResourceName]string
+		resources  corev1.ResourceList
+	}{{"capacity", pool.Capacity, node.Status.Capacity}, {"allocatable", pool.Allocatable, node.Status.Allocatable}} {
+		for _, name := range sortedResourceNames(list.thresholds) {
+			t, err := parseThreshold(list.thresholds[name])
+			if err != nil {
+				continue
+			}
+			q, ok := list.resources[name]
+			if !ok {
+				mismatch(fmt.Sprintf("%s %s", list.name, name), "not reported", t.String())
+				continue
+			}
+			if !t.matches(q) {
+				mismatch(fmt.Sprintf("%s %s", list.name, name), q.String(), t.String())
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(pool.Labels))
+	for key := range pool.Labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		value, ok := node.Labels[key]
+		switch {
+		case !ok:
+			mismatch("label "+key, "missing", labelExpectation(pool.Labels[key]))
+		case pool.Labels[key] != "" && value != pool.Labels[key]:
+			mismatch("label "+key, fmt.Sprintf("%q", value), labelExpectation(pool.Labels[key]))
+		}
+	}
+
+	for _, required := range pool.Taints {
+		if !hasTaint(node.Spec.Taints, required) {
+			mismatch("taint "+required.Key, "missing", taintExpectation(required))
+		}
+	}
+	return mismatches
+}
+
+func sortedResourceNames(thresholds map[corev1.ResourceName]string) []corev1.ResourceName {
+	names := make([]corev1.ResourceName, 0, len(thresholds))
+	for name := range thresholds {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return names
+}
+
+func labelExpectation(value string) string {
+	if value == "" {
+		return "present"
+	}
+	return fmt.Sprintf("%q", value)
+}
+
+// An empty value or effect of the required taint matches any
+func hasTaint(taints []corev1.Taint, required corev1.Taint) bool {
+	for _, taint := range taints {
+		if taint.Key == required.Key && (required.Value == "" || taint.Value == required.Value) && (required.Effect == "" || taint.Effect == required.Effect) {
+			return true
+		}
+	}
+	return false
+}
+
+func taintExpectation(taint corev1.Taint) string {
+	expected := taint.Key
+	if taint.Value != "" {
+		expected += "=" + taint.Value
+	}
+	if taint.Effect != "" {
+		expected += ":" + string(taint.Effect)
+	}
+	return expected
+}
+
+// FormatDriftTable renders drift or mismatches as a table with a row per node and field, for test logs
+func FormatDriftTable(drift []Drift) string {
+	var b strings.Builder
+	w := tabwriter.NewWriter(&b, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NODE\tPOOL\tFIELD\tVALUE\tEXPECTED")
+	for _, d := range drift {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", d.Node, d.Pool, d.Field, d.Value, d.Expected)
+	}
+	w.Flush()
+	return b.String()
+}
@@ -0,0 +1,159 @@
+package utils
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+func TestParseThreshold(t *testing.T) {
+	tests := []struct {
+		name    string
+		s       string
+		want    string
+		wantErr bool
+		// Quantities the threshold must and must not match
+		matches    []string
+		notMatches []string
+	}{
+		{name: "bare value is a minimum", s: "16Gi", want: ">=16Gi", matches: []string{"16Gi", "32Gi"}, notMatches: []string{"8Gi"}},
+		{name: "at least", s: ">= 1", want: ">=1", matches: []string{"1", "110"}, notMatches: []string{"0"}},
+		{name: "at most", s: "<=4", want: "<=4", matches: []string{"4", "3500m"}, notMatches: []string{"5"}},
+		{name: "more than", s: ">0", want: ">0", matches: []string{"1"}, notMatches: []string{"0"}},
+		{name: "less than", s: "<1Gi", want: "<1Gi", matches: []string{"512Mi"}, notMatches: []string{"1024Mi"}},
+		{name: "equal across units", s: "=1Gi", want: "=1Gi", matches: []string{"1024Mi"}, notMatches: []string{"1G"}},
+		{name: "empty", s: "", wantErr: true},
+		{name: "operator only", s: ">=", wantErr: true},
+		{name: "not a quantity", s: "lots", wantErr: true},
+	}
+
+	feat := features.New("Node resource thresholds").
+		WithLabel("type", "Node").
+		Assess("Test parsing and matching resource thresholds", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					threshold, err := parseThreshold(tt.s)
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+					}
+					if err != nil {
+						return
+					}
+					if threshold.String() != tt.want {
+						t.Errorf("got %s, want %s", threshold, tt.want)
+					}
+					for _, q := range tt.matches {
+						if !threshold.matches(resource.MustParse(q)) {
+							t.Errorf("%s does not match %s", q, threshold)
+						}
+					}
+					for _, q := range tt.notMatches {
+						if threshold.matches(resource.MustParse(q)) {
+							t.Errorf("%s matches %s", q, threshold)
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestCheckNodeResources(t *testing.T) {
+	pool := &PoolBaseline{
+		Name:        "worker",
+		Capacity:    map[corev1.ResourceName]string{"devices.kubevirt.io/kvm": "1"},
+		Allocatable: map[corev1.ResourceName]string{corev1.ResourceCPU: "16", "hugepages-1Gi": "16Gi", corev1.ResourcePods: "<=250"},
+		Labels:      map[string]string{"topology.kubernetes.io/zone": "", "cpumanager": "true"},
+		Taints:      []corev1.Taint{{Key: "dedicated", Value: "vms"}},
+	}
+	node := func(mutate func(n *corev1.Node)) *corev1.Node {
+		n := &corev1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "worker-0",
+				Labels: map[string]string{"topology.kubernetes.io/zone": "az-a", "cpumanager": "true"},
+			},
+			Spec: corev1.NodeSpec{Taints: []corev1.Taint{{Key: "dedicated", Value: "vms", Effect: corev1.TaintEffectNoSchedule}}},
+			Status: corev1.NodeStatus{
+				Capacity: corev1.ResourceList{"devices.kubevirt.io/kvm": resource.MustParse("1k")},
+				Allocatable: corev1.ResourceList{
+					corev1.ResourceCPU:  resource.MustParse("63500m"),
+					"hugepages-1Gi":     resource.MustParse("32Gi"),
+					corev1.ResourcePods: resource.MustParse("250"),
+				},
+			},
+		}
+		mutate(n)
+		return n
+	}
+
+	tests := []struct {
+		name string
+		node *corev1.Node
+		// "<field> <value>" of every expected mismatch, in order
+		want []string
+	}{
+		{
+			name: "matching node",
+			node: node(func(n *corev1.Node) {}),
+		},
+		{
+			name: "resources outside the thresholds",
+			node: node(func(n *corev1.Node) {
+				n.Status.Allocatable[corev1.ResourceCPU] = resource.MustParse("8")
+				n.Status.Allocatable[corev1.ResourcePods] = resource.MustParse("500")
+			}),
+			want: []string{"allocatable cpu 8", "allocatable pods 500"},
+		},
+		{
+			name: "resources not reported",
+			node: node(func(n *corev1.Node) {
+				n.Status.Capacity = corev1.ResourceList{}
+				delete(n.Status.Allocatable, "hugepages-1Gi")
+			}),
+			want: []string{"capacity devices.kubevirt.io/kvm not reported", "allocatable hugepages-1Gi not reported"},
+		},
+		{
+			name: "labels missing or different",
+			node: node(func(n *corev1.Node) {
+				delete(n.Labels, "topology.kubernetes.io/zone")
+				n.Labels["cpumanager"] = "false"
+			}),
+			want: []string{`label cpumanager "false"`, "label topology.kubernetes.io/zone missing"},
+		},
+		{
+			name: "taint with another value",
+			node: node(func(n *corev1.Node) {
+				n.Spec.Taints[0].Value = "infra"
+			}),
+			want: []string{"taint dedicated missing"},
+		},
+	}
+
+	feat := features.New("Node resources check").
+		WithLabel("type", "Node").
+		Assess("Test checking node resources, labels and taints against the pool", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					mismatches := checkNodeResources(tt.node, pool)
+					if len(mismatches) != len(tt.want) {
+						t.Fatalf("got %d mismatches, want %d:\n%s", len(mismatches), len(tt.want), FormatDriftTable(mismatches))
+					}
+					for i, m := range mismatches {
+						if got := m.Field + " " + m.Value; got != tt.want[i] || m.Node != "worker-0" || m.Pool != "worker" {
+							t.Errorf("mismatch %d is %v, want %s", i, m, tt.want[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -1,14 +1,18 @@
 package utils
 
 import (
+	"fmt"
+
 	corev1 "k8s.io/api/core/v1"
 	resource "k8s.io/apimachinery/pkg/api/resource"
 )
 
 const (
-	requestsToLimitsRation int64 = 4
+	// Default CPU limits to requests ratio
+	RequestsToLimitsRatio int64 = 4
 )
 
+// Quantities which fail to parse are left out, use ParseResourceList to surface them
 func GenerateResourceList(cpu, memory, storage, ephStorage string) *corev1.ResourceList {
 	var resourceList = make(corev1.ResourceList)
 
@@ -27,6 +31,32 @@ func GenerateResourceList(cpu, memory, storage, ephStorage string) *corev1.Resou
 	return &resourceList
 }
 
+// Same as GenerateResourceList, but returns an error for any non empty quantity which fails to parse
+func ParseResourceList(cpu, memory, storage, ephStorage string) (*corev1.ResourceList, error) {
+	var resourceList = make(corev1.ResourceList)
+
+	quantities := []struct {
+		name  corev1.ResourceName
+		value string
+	}{
+		{corev1.ResourceCPU, cpu},
+		{corev1.ResourceMemory, memory},
+		{corev1.ResourceStorage, storage},
+		{corev1.ResourceEphemeralStorage, ephStorage},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		parsed, err := resource.ParseQuantity(q.value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s quantity %q: %v", q.name, q.value, err)
+		}
+		resourceList[q.name] = parsed
+	}
+	return &resourceList, nil
+}
+
 func multiplyQuantity(q *resource.Quantity, factor int64) *resource.Quantity {
 	// Get the value in millicores to maintain precision
 	originalMilliValue := q.MilliValue()
@@ -40,6 +70,10 @@ func multiplyQuantity(q *resource.Quantity, factor int64) *resource.Quantity {
 
 // Usually the ratio between CPU requests and CPU limits is 1:4
 func GetCPULimitsFromRequests(requests string) string {
+	return GetCPULimitsFromRequestsWithRatio(requests, RequestsToLimitsRatio)
+}
+
+func GetCPULimitsFromRequestsWithRatio(requests string, ratio int64) string {
 	// Parse the CPU requests string to a Quantity
 	parsed, err := resource.ParseQuantity(requests)
 	if err != nil {
@@ -48,8 +82,22 @@ func GetCPULimitsFromRequests(requests string) string {
 	}
 
 	// Multiply the parsed Quantity by the ratio
-	limits := multiplyQuantity(&parsed, requestsToLimitsRation)
+	limits := multiplyQuantity(&parsed, ratio)
 
 	// Return the new limits Quantity as string
 	return limits.String()
 }
+
+// Returns the quantity scaled down by an overcommit percentage, e.g. 200 halves it.
+// Used to derive requests from what the guest is given, the same way KubeVirt applies memoryOvercommit
+func ScaleQuantityByOvercommit(q resource.Quantity, percent int64) resource.Quantity {
+	if percent <= 0 {
+		return q
+	}
+	scaled := q.MilliValue() * 100 / percent
+	// Fractions of a byte can not be requested
+	if q.Format == resource.BinarySI {
+		return *resource.NewQuantity(scaled/1000, resource.BinarySI)
+	}
+	return *resource.NewMilliQuantity(scaled, q.Format)
+}
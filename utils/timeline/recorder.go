@@ -0,0 +1,375 @@
+package timeline
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Wait before listing again once a watch can not be resumed, e.g. its resourceVersion expired
+	relistInterval time.Duration = 5 * time.Second
+)
+
+// Last observed state of an object, the next one is compared to it to find the milestones reached in between
+type state struct {
+	generation int64
+	phase      string
+	conditions map[string]corev1.ConditionStatus
+	deleting   bool
+}
+
+// Recorder watches the objects a feature touches and the Events about them, and keeps a Timeline per object.
+// Start it before creating or changing the objects, milestones reached before are timed when first observed
+type Recorder struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu        sync.Mutex
+	timelines map[types.UID]*Timeline
+	order     []types.UID
+	states    map[types.UID]*state
+	// Events received before their object, merged into its timeline once it shows up
+	pendingEvents map[types.UID][]Entry
+	// Namespaces Events are watched in
+	eventNamespaces map[string]bool
+	errs            []error
+}
+
+func NewRecorder() *Recorder {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Recorder{
+		ctx:             ctx,
+		cancel:          cancel,
+		timelines:       map[types.UID]*Timeline{},
+		states:          map[types.UID]*state{},
+		pendingEvents:   map[types.UID][]Entry{},
+		eventNamespaces: map[string]bool{},
+	}
+}
+
+// Watch records the object named like obj, whether it exists yet or not, e.g. the VMI of a VM about to start
+func (r *Recorder) Watch(obj k8s.Object) func(ctx context.Context, c *envconf.Config) error {
+	return r.watch(obj, metav1.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", obj.GetName()).String()})
+}
+
+// WatchLabeled records every object of the type of obj in its namespace matching the label selector,
+// e.g. the pods of a Deployment, including the ones created later
+func (r *Recorder) WatchLabeled(obj k8s.Object, labelSelector string) func(ctx context.Context, c *envconf.Config) error {
+	return r.watch(obj, metav1.ListOptions{LabelSelector: labelSelector})
+}
+
+func (r *Recorder) watch(obj k8s.Object, selector metav1.ListOptions) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		resource, err := vmconditions.ResourceFor(c, obj)
+		if err != nil {
+			return err
+		}
+
+		list := func(ctx context.Context) (string, error) {
+			list, err := resource.List(ctx, selector)
+			if err != nil {
+				return "", err
+			}
+			for i := range list.Items {
+				r.observe(&list.Items[i])
+			}
+			return list.GetResourceVersion(), nil
+		}
+		watchFunc := func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector.FieldSelector
+			options.LabelSelector = selector.LabelSelector
+			return resource.Watch(r.ctx, options)
+		}
+		handle := func(event watch.Event) {
+			u, ok := event.Object.(*unstructured.Unstructured)
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				r.observe(u)
+			case watch.Deleted:
+				r.observeDeleted(u)
+			}
+		}
+		if err := r.run(ctx, list, watchFunc, handle); err != nil {
+			return fmt.Errorf("failed to watch %s %s: %v", obj.GetObjectKind().GroupVersionKind().Kind, obj.GetName(), err)
+		}
+		return r.watchEvents(ctx, c, obj.GetNamespace())
+	}
+}
+
+// Events are matched to the recorded objects by UID, whatever selected them
+func (r *Recorder) watchEvents(ctx context.Context, c *envconf.Config, ns string) error {
+	r.mu.Lock()
+	watched := r.eventNamespaces[ns]
+	r.eventNamespaces[ns] = true
+	r.mu.Unlock()
+	if watched {
+		return nil
+	}
+
+	clientset, err := kubernetes.NewForConfig(c.Client().RESTConfig())
+	if err != nil {
+		return err
+	}
+	events := clientset.CoreV1().Events(ns)
+
+	// Past Events are not recorded, the list only returns the resourceVersion to watch from
+	list := func(ctx context.Context) (string, error) {
+		list, err := events.List(ctx, metav1.ListOptions{Limit: 1})
+		if err != nil {
+			return "", err
+		}
+		return list.GetResourceVersion(), nil
+	}
+	watchFunc := func(options metav1.ListOptions) (watch.Interface, error) {
+		return events.Watch(r.ctx, options)
+	}
+	handle := func(event watch.Event) {
+		if e, ok := event.Object.(*corev1.Event); ok && (event.Type == watch.Added || event.Type == watch.Modified) {
+			r.observeEvent(e)
+		}
+	}
+	if err := r.run(ctx, list, watchFunc, handle); err != nil {
+		return fmt.Errorf("failed to watch events in %s: %v", ns, err)
+	}
+	return nil
+}
+
+// Lists once before returning, so a failure to access the resource fails the caller, then watches in the
+// background until Stop. A watch which can not be resumed is replaced after listing again
+func (r *Recorder) run(ctx context.Context, list func(ctx context.Context) (string, error), watchFunc cache.WatchFunc, handle func(watch.Event)) error {
+	resourceVersion, err := list(ctx)
+	if err != nil {
+		return err
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		for {
+			if resourceVersion != "" {
+				r.consume(resourceVersion, watchFunc, handle)
+			}
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-time.After(relistInterval):
+			}
+
+			rv, err := list(r.ctx)
+			if err != nil {
+				r.mu.Lock()
+				r.errs = append(r.errs, err)
+				r.mu.Unlock()
+			}
+			resourceVersion = rv
+		}
+	}()
+	return nil
+}
+
+// Returns when the recorder is stopped or the watch broke
+func (r *Recorder) consume(resourceVersion string, watchFunc cache.WatchFunc, handle func(watch.Event)) {
+	watcher, err := watchtools.NewRetryWatcher(resourceVersion, &cache.ListWatch{WatchFunc: watchFunc})
+	if err != nil {
+		r.mu.Lock()
+		r.errs = append(r.errs, err)
+		r.mu.Unlock()
+		return
+	}
+	defer watcher.Stop()
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-watcher.Done():
+			return
+		case event, ok := <-watcher.ResultChan():
+			if !ok || event.Type == watch.Error {
+				return
+			}
+			handle(event)
+		}
+	}
+}
+
+func (r *Recorder) observe(u *unstructured.Unstructured) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	tl := r.timelineOf(u, now)
+	prev, seen := r.states[u.GetUID()]
+	next := &state{
+		generation: u.GetGeneration(),
+		conditions: map[string]corev1.ConditionStatus{},
+		deleting:   u.GetDeletionTimestamp() != nil,
+	}
+	next.phase, _, _ = unstructured.NestedString(u.Object, "status", "phase")
+	conditions, _ := vmconditions.GetConditions(u)
+	for _, cond := range conditions {
+		next.conditions[cond.Type] = cond.Status
+	}
+	if !seen {
+		prev = &state{generation: next.generation, conditions: map[string]corev1.ConditionStatus{}}
+	}
+
+	var entries []Entry
+	if next.generation != prev.generation {
+		entries = append(entries, Entry{Time: now, Milestone: Generation(next.generation)})
+	}
+	if next.phase != "" && next.phase != prev.phase {
+		entries = append(entries, Entry{Time: now, Milestone: Phase(next.phase)})
+	}
+	// In the order the object reports them
+	for _, cond := range conditions {
+		if status, ok := prev.conditions[cond.Type]; !ok || status != cond.Status {
+			entries = append(entries, Entry{Time: now, Milestone: Condition(cond.Type, cond.Status)})
+		}
+	}
+	if next.deleting && !prev.deleting {
+		entries = append(entries, Entry{Time: now, Milestone: Deleting})
+	}
+	tl.add(entries...)
+	r.states[u.GetUID()] = next
+}
+
+func (r *Recorder) observeDeleted(u *unstructured.Unstructured) {
+	now := time.Now()
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.timelineOf(u, now).add(Entry{Time: now, Milestone: Deleted})
+}
+
+func (r *Recorder) observeEvent(e *corev1.Event) {
+	entry := Entry{Time: time.Now(), Milestone: Event(e.Reason), Detail: e.Message}
+	if e.Count > 1 {
+		entry.Detail = fmt.Sprintf("%s (x%d)", e.Message, e.Count)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if tl, ok := r.timelines[e.InvolvedObject.UID]; ok {
+		tl.add(entry)
+		return
+	}
+	r.pendingEvents[e.InvolvedObject.UID] = append(r.pendingEvents[e.InvolvedObject.UID], entry)
+}
+
+// Created is timed when the object is first seen rather than by its creationTimestamp, which comes from
+// the API server's clock, so all milestones are on the clock of the recorder. Must be called with the lock held
+func (r *Recorder) timelineOf(u *unstructured.Unstructured, now time.Time) *Timeline {
+	if tl, ok := r.timelines[u.GetUID()]; ok {
+		return tl
+	}
+	tl := &Timeline{Kind: u.GetKind(), Namespace: u.GetNamespace(), Name: u.GetName(), UID: u.GetUID()}
+	tl.add(Entry{Time: now, Milestone: Created})
+	tl.add(r.pendingEvents[u.GetUID()]...)
+	delete(r.pendingEvents, u.GetUID())
+	r.timelines[u.GetUID()] = tl
+	r.order = append(r.order, u.GetUID())
+	return tl
+}
+
+// Stop ends every watch. It returns the errors of listing again after a watch broke, during which
+// milestones may have been missed or timed late
+func (r *Recorder) Stop() error {
+	r.cancel()
+	r.wg.Wait()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.errs) > 0 {
+		return fmt.Errorf("timelines may be incomplete, %d relists failed, last: %v", len(r.errs), r.errs[len(r.errs)-1])
+	}
+	return nil
+}
+
+// Timelines returns a copy of every recorded timeline, in the order the objects were first observed
+func (r *Recorder) Timelines() []Timeline {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	timelines := make([]Timeline, 0, len(r.order))
+	for _, uid := range r.order {
+		tl := *r.timelines[uid]
+		tl.Entries = append([]Entry(nil), tl.Entries...)
+		timelines = append(timelines, tl)
+	}
+	return timelines
+}
+
+// Time returns when the point was reached. For a group, i.e. a point without a name, the objects which
+// never reached the milestone are left out, e.g. the pods still running when Deleted is asked for
+func (r *Recorder) Time(p Point) (time.Time, error) {
+	var reached time.Time
+	for _, tl := range r.Timelines() {
+		if tl.Kind != p.Kind || (p.Name != "" && tl.Name != p.Name) {
+			continue
+		}
+		if at, ok := tl.At(p.Milestone); ok && at.After(reached) {
+			reached = at
+		}
+	}
+	if reached.IsZero() {
+		return reached, fmt.Errorf("%s was not reached", p)
+	}
+	return reached, nil
+}
+
+// Between returns how long it took from one point to the other
+func (r *Recorder) Between(from, to Point) (time.Duration, error) {
+	start, err := r.Time(from)
+	if err != nil {
+		return 0, err
+	}
+	end, err := r.Time(to)
+	if err != nil {
+		return 0, err
+	}
+	return end.Sub(start), nil
+}
+
+// Within returns how long it took from one point to the other, and an error when it took longer than max
+func (r *Recorder) Within(from, to Point, max time.Duration) (time.Duration, error) {
+	d, err := r.Between(from, to)
+	if err != nil {
+		return d, err
+	}
+	if d > max {
+		return d, fmt.Errorf("%s to %s took %s, more than %s", from, to, d.Round(time.Millisecond), max)
+	}
+	return d, nil
+}
+
+// Report renders every timeline, for test logs and reports
+func (r *Recorder) Report() string {
+	timelines := r.Timelines()
+	reports := make([]string, 0, len(timelines))
+	for i := range timelines {
+		reports = append(reports, timelines[i].String())
+	}
+	return strings.Join(reports, "\n")
+}
@@ -0,0 +1,228 @@
+package timeline
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+// Recorder holding the given timelines as if they were observed in that order
+func recorderOf(timelines ...Timeline) *Recorder {
+	r := NewRecorder()
+	for i := range timelines {
+		tl := timelines[i]
+		r.timelines[tl.UID] = &tl
+		r.order = append(r.order, tl.UID)
+	}
+	return r
+}
+
+func TestRecorderBetween(t *testing.T) {
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	at := func(offset time.Duration, milestone string) Entry {
+		return Entry{Time: start.Add(offset), Milestone: milestone}
+	}
+	ready := Condition("Ready", corev1.ConditionTrue)
+	r := recorderOf(
+		Timeline{Kind: "VirtualMachine", Name: "vm-0", UID: "vm", Entries: []Entry{
+			at(0, Created), at(40*time.Second, ready),
+		}},
+		Timeline{Kind: "VirtualMachineInstance", Name: "vm-0", UID: "vmi", Entries: []Entry{
+			at(time.Second, Created), at(10*time.Second, Phase("Scheduled")), at(20*time.Second, Phase("Running")),
+		}},
+		Timeline{Kind: "Pod", Name: "virt-launcher-a", UID: "pod-a", Entries: []Entry{
+			at(2*time.Second, Created), at(15*time.Second, ready), at(30*time.Second, Condition("Ready", corev1.ConditionFalse)), at(35*time.Second, ready), at(time.Minute, Deleted),
+		}},
+		Timeline{Kind: "Pod", Name: "virt-launcher-b", UID: "pod-b", Entries: []Entry{
+			at(3*time.Second, Created), at(25*time.Second, ready),
+		}},
+	)
+
+	tests := []struct {
+		name     string
+		from, to Point
+		// Within is checked when set, Between otherwise
+		max     time.Duration
+		want    time.Duration
+		wantErr bool
+	}{
+		{
+			name: "milestones of one object",
+			from: Point{Kind: "VirtualMachineInstance", Name: "vm-0", Milestone: Created},
+			to:   Point{Kind: "VirtualMachineInstance", Name: "vm-0", Milestone: Phase("Running")},
+			want: 19 * time.Second,
+		},
+		{
+			name: "group reaches a milestone with its last object",
+			from: Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: Created},
+			to:   Point{Kind: "Pod", Milestone: ready},
+			want: 25 * time.Second,
+		},
+		{
+			name: "milestone reached again counts the first time",
+			from: Point{Kind: "Pod", Name: "virt-launcher-a", Milestone: Created},
+			to:   Point{Kind: "Pod", Name: "virt-launcher-a", Milestone: ready},
+			want: 13 * time.Second,
+		},
+		{
+			name: "group leaves out objects which never reached the milestone",
+			from: Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: Created},
+			to:   Point{Kind: "Pod", Milestone: Deleted},
+			want: time.Minute,
+		},
+		{
+			name:    "milestone not reached",
+			from:    Point{Kind: "VirtualMachineInstance", Name: "vm-0", Milestone: Created},
+			to:      Point{Kind: "VirtualMachineInstance", Name: "vm-0", Milestone: Phase("Failed")},
+			wantErr: true,
+		},
+		{
+			name:    "object not recorded",
+			from:    Point{Kind: "VirtualMachineInstance", Name: "vm-1", Milestone: Created},
+			to:      Point{Kind: "VirtualMachineInstance", Name: "vm-0", Milestone: Phase("Running")},
+			wantErr: true,
+		},
+		{
+			name: "within the limit",
+			from: Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: Created},
+			to:   Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: ready},
+			max:  time.Minute,
+			want: 40 * time.Second,
+		},
+		{
+			name:    "over the limit",
+			from:    Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: Created},
+			to:      Point{Kind: "VirtualMachine", Name: "vm-0", Milestone: ready},
+			max:     30 * time.Second,
+			want:    40 * time.Second,
+			wantErr: true,
+		},
+	}
+
+	feat := features.New("Timeline durations").
+		WithLabel("type", "Timeline").
+		Assess("Test measuring between milestones of objects and groups", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					var got time.Duration
+					var err error
+					if tt.max > 0 {
+						got, err = r.Within(tt.from, tt.to, tt.max)
+					} else {
+						got, err = r.Between(tt.from, tt.to)
+					}
+					if (err != nil) != tt.wantErr {
+						t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+					}
+					if got != tt.want {
+						t.Errorf("got %s, want %s", got, tt.want)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestRecorderObserve(t *testing.T) {
+	object := func(phase string, deleting bool, conditions ...string) *unstructured.Unstructured {
+		u := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		u.SetKind("VirtualMachineInstance")
+		u.SetName("vm-0")
+		u.SetUID(types.UID("vmi"))
+		u.SetGeneration(1)
+		if phase != "" {
+			unstructured.SetNestedField(u.Object, phase, "status", "phase")
+		}
+		var conds []interface{}
+		for i := 0; i+1 < len(conditions); i += 2 {
+			conds = append(conds, map[string]interface{}{"type": conditions[i], "status": conditions[i+1]})
+		}
+		if len(conds) > 0 {
+			unstructured.SetNestedSlice(u.Object, conds, "status", "conditions")
+		}
+		if deleting {
+			now := metav1.Now()
+			u.SetDeletionTimestamp(&now)
+		}
+		return u
+	}
+
+	tests := []struct {
+		name    string
+		objects []*unstructured.Unstructured
+		want    []string
+	}{
+		{
+			name:    "first observation",
+			objects: []*unstructured.Unstructured{object("Scheduling", false, "Ready", "False")},
+			want:    []string{Created, Phase("Scheduling"), Condition("Ready", corev1.ConditionFalse)},
+		},
+		{
+			name: "unchanged observations add nothing",
+			objects: []*unstructured.Unstructured{
+				object("Scheduling", false, "Ready", "False"),
+				object("Scheduling", false, "Ready", "False"),
+				object("Running", false, "Ready", "False"),
+				object("Running", false, "Ready", "False"),
+			},
+			want: []string{Created, Phase("Scheduling"), Condition("Ready", corev1.ConditionFalse), Phase("Running")},
+		},
+		{
+			name: "condition flapping is recorded every time",
+			objects: []*unstructured.Unstructured{
+				object("Running", false, "Ready", "True"),
+				object("Running", false, "Ready", "False"),
+				object("Running", false, "Ready", "True"),
+			},
+			want: []string{Created, Phase("Running"), Condition("Ready", corev1.ConditionTrue), Condition("Ready", corev1.ConditionFalse), Condition("Ready", corev1.ConditionTrue)},
+		},
+		{
+			name: "deletion",
+			objects: []*unstructured.Unstructured{
+				object("Running", false),
+				object("Running", true),
+				object("Running", true),
+			},
+			want: []string{Created, Phase("Running"), Deleting},
+		},
+	}
+
+	feat := features.New("Timeline recording").
+		WithLabel("type", "Timeline").
+		Assess("Test recording the milestones reached between observations", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					r := NewRecorder()
+					for _, u := range tt.objects {
+						r.observe(u)
+					}
+
+					timelines := r.Timelines()
+					if len(timelines) != 1 {
+						t.Fatalf("got %d timelines, want 1", len(timelines))
+					}
+					entries := timelines[0].Entries
+					if len(entries) != len(tt.want) {
+						t.Fatalf("got %d milestones, want %d:\n%s", len(entries), len(tt.want), timelines[0].String())
+					}
+					for i, milestone := range tt.want {
+						if entries[i].Milestone != milestone {
+							t.Errorf("milestone %d is %q, want %q", i, entries[i].Milestone, milestone)
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -0,0 +1,116 @@
+package timeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Milestones every object can reach
+const (
+	// Timed when the object is first observed, so when the recorder started for an object which already existed
+	Created  string = "created"
+	Deleting string = "deleting"
+	Deleted  string = "deleted"
+)
+
+// Phase is the milestone of status.phase becoming phase, e.g. Phase("Scheduled") of a VMI
+func Phase(phase string) string {
+	return "phase " + phase
+}
+
+// Condition is the milestone of a status.conditions entry changing to status
+func Condition(conditionType string, status corev1.ConditionStatus) string {
+	return fmt.Sprintf("condition %s=%s", conditionType, status)
+}
+
+// Generation is the milestone of the spec being changed to metadata.generation, e.g. a patched Deployment
+func Generation(generation int64) string {
+	return fmt.Sprintf("generation %d", generation)
+}
+
+// Event is the milestone of an Event with the reason being reported about the object, e.g. Event("Pulled")
+func Event(reason string) string {
+	return "event " + reason
+}
+
+// Entry is a milestone reached by an object, timed when the recorder received it
+type Entry struct {
+	Time      time.Time
+	Milestone string
+	// Message of the Event, empty for other milestones
+	Detail string
+}
+
+// Timeline holds the milestones of a single object in the order they were reached
+type Timeline struct {
+	Kind      string
+	Namespace string
+	Name      string
+	UID       types.UID
+	Entries   []Entry
+}
+
+// At returns when the object first reached the milestone
+func (t *Timeline) At(milestone string) (time.Time, bool) {
+	for _, e := range t.Entries {
+		if e.Milestone == milestone {
+			return e.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// Between returns how long the object took from one milestone to the other
+func (t *Timeline) Between(from, to string) (time.Duration, error) {
+	start, ok := t.At(from)
+	if !ok {
+		return 0, fmt.Errorf("%s %s did not reach %s", t.Kind, t.Name, from)
+	}
+	end, ok := t.At(to)
+	if !ok {
+		return 0, fmt.Errorf("%s %s did not reach %s", t.Kind, t.Name, to)
+	}
+	return end.Sub(start), nil
+}
+
+// String lists the milestones with their offset from the first one, for test logs and reports
+func (t *Timeline) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s/%s", t.Kind, t.Namespace, t.Name)
+	if len(t.Entries) == 0 {
+		return b.String()
+	}
+	start := t.Entries[0].Time
+	for _, e := range t.Entries {
+		fmt.Fprintf(&b, "\n  %-10s %s", "+"+e.Time.Sub(start).Round(time.Millisecond).String(), e.Milestone)
+		if e.Detail != "" {
+			fmt.Fprintf(&b, ": %s", e.Detail)
+		}
+	}
+	return b.String()
+}
+
+func (t *Timeline) add(entries ...Entry) {
+	t.Entries = append(t.Entries, entries...)
+	sort.SliceStable(t.Entries, func(i, j int) bool { return t.Entries[i].Time.Before(t.Entries[j].Time) })
+}
+
+// Point designates a milestone of an object, or of a group of objects of the same kind when Name is empty,
+// e.g. the pods of a Deployment. A group reaches the milestone when its last object does
+type Point struct {
+	Kind      string
+	Name      string
+	Milestone string
+}
+
+func (p Point) String() string {
+	if p.Name == "" {
+		return fmt.Sprintf("%s of every %s", p.Milestone, p.Kind)
+	}
+	return fmt.Sprintf("%s of %s %s", p.Milestone, p.Kind, p.Name)
+}
@@ -0,0 +1,57 @@
+package vm
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clonev1alpha1 "kubevirt.io/api/clone/v1alpha1"
+)
+
+const (
+	kubevirtAPIGroup        string = "kubevirt.io"
+	virtualMachineKind      string = "VirtualMachine"
+	cloneAPIVersion         string = "clone.kubevirt.io/v1alpha1"
+	virtualMachineCloneKind string = "VirtualMachineClone"
+)
+
+func GenerateVirtualMachineClone(c VMClone) *clonev1alpha1.VirtualMachineClone {
+	vmc := clonev1alpha1.VirtualMachineClone{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       virtualMachineCloneKind,
+			APIVersion: cloneAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      c.CloneName,
+			Namespace: c.Namespace,
+		},
+		Spec: *generateVirtualMachineCloneSpec(c),
+	}
+	return &vmc
+}
+
+func generateVirtualMachineCloneSpec(c VMClone) *clonev1alpha1.VirtualMachineCloneSpec {
+	spec := clonev1alpha1.VirtualMachineCloneSpec{
+		Source:            generateVMTypedReference(c.SourceVMName),
+		LabelFilters:      c.LabelFilters,
+		AnnotationFilters: c.AnnotationFilters,
+		Template: clonev1alpha1.VirtualMachineCloneTemplateFilters{
+			LabelFilters:      c.TemplateLabelFilters,
+			AnnotationFilters: c.TemplateAnnotationFilters,
+		},
+		NewMacAddresses: c.NewMacAddresses,
+		NewSMBiosSerial: c.NewSMBiosSerial,
+	}
+	// Target is optional, KubeVirt will generate a name if it is not provided
+	if c.TargetVMName != "" {
+		spec.Target = generateVMTypedReference(c.TargetVMName)
+	}
+	return &spec
+}
+
+func generateVMTypedReference(name string) *corev1.TypedLocalObjectReference {
+	apiGroup := kubevirtAPIGroup
+	return &corev1.TypedLocalObjectReference{
+		APIGroup: &apiGroup,
+		Kind:     virtualMachineKind,
+		Name:     name,
+	}
+}
@@ -0,0 +1,205 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	consoleSubprotocol string = "plain.kubevirt.io"
+	// Printed after every command so its exit code can be told apart from its output
+	exitCodeMarker string = "node-e2e-rc"
+)
+
+var (
+	loginPromptRegex    = regexp.MustCompile(`login: $`)
+	passwordPromptRegex = regexp.MustCompile(`Password: $`)
+	shellPromptRegex    = regexp.MustCompile(`[$#] $`)
+	exitCodeRegex       = regexp.MustCompile(exitCodeMarker + `:(\d+):`)
+)
+
+// Console is the serial console of a VMI, as virtctl console connects to it
+type Console struct {
+	conn *websocket.Conn
+
+	mu  sync.Mutex
+	buf []byte
+	err error
+	// Signalled whenever output arrives or the connection breaks
+	notify chan struct{}
+}
+
+// OpenConsole connects to the serial console of the VMI through virt-api.
+// Only one console connection per VMI is allowed, the caller must Close it
+func OpenConsole(vmiName, ns string) func(ctx context.Context, c *envconf.Config) (*Console, error) {
+	return func(ctx context.Context, c *envconf.Config) (*Console, error) {
+		cfg := c.Client().RESTConfig()
+
+		tlsConfig, err := rest.TLSConfigFor(cfg)
+		if err != nil {
+			return nil, err
+		}
+		host, err := url.Parse(cfg.Host)
+		if err != nil {
+			return nil, err
+		}
+		consoleURL := url.URL{
+			Scheme: "wss",
+			Host:   host.Host,
+			Path:   path.Join(host.Path, subresourcesPath, "namespaces", ns, "virtualmachineinstances", vmiName, "console"),
+		}
+		if host.Scheme == "http" {
+			consoleURL.Scheme = "ws"
+		}
+
+		header := http.Header{}
+		if cfg.BearerToken != "" {
+			header.Set("Authorization", "Bearer "+cfg.BearerToken)
+		}
+		dialer := websocket.Dialer{
+			TLSClientConfig:  tlsConfig,
+			Subprotocols:     []string{consoleSubprotocol},
+			HandshakeTimeout: 30 * time.Second,
+		}
+		conn, resp, err := dialer.DialContext(ctx, consoleURL.String(), header)
+		if err != nil {
+			if resp != nil {
+				return nil, fmt.Errorf("failed to open console of VirtualMachineInstance %s: %s: %v", vmiName, resp.Status, err)
+			}
+			return nil, fmt.Errorf("failed to open console of VirtualMachineInstance %s: %v", vmiName, err)
+		}
+
+		console := &Console{
+			conn:   conn,
+			notify: make(chan struct{}, 1),
+		}
+		go console.read()
+		return console, nil
+	}
+}
+
+func (con *Console) read() {
+	for {
+		_, data, err := con.conn.ReadMessage()
+		con.mu.Lock()
+		con.buf = append(con.buf, data...)
+		con.err = err
+		con.mu.Unlock()
+
+		select {
+		case con.notify <- struct{}{}:
+		default:
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Send writes s to the console as if it was typed
+func (con *Console) Send(s string) error {
+	return con.conn.WriteMessage(websocket.BinaryMessage, []byte(s))
+}
+
+// Expect waits until the console output matches re and returns the output up to the end of the match.
+// The returned output is consumed, so the next Expect only sees what follows it
+func (con *Console) Expect(re *regexp.Regexp, timeout time.Duration) (string, error) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		con.mu.Lock()
+		if loc := re.FindIndex(con.buf); loc != nil {
+			out := string(con.buf[:loc[1]])
+			con.buf = con.buf[loc[1]:]
+			con.mu.Unlock()
+			return out, nil
+		}
+		out, err := string(con.buf), con.err
+		con.mu.Unlock()
+		if err != nil {
+			return out, fmt.Errorf("console closed while expecting %q: %v", re, err)
+		}
+
+		select {
+		case <-con.notify:
+		case <-timer.C:
+			return out, fmt.Errorf("timed out after %s expecting %q, got %q", timeout, re, out)
+		}
+	}
+}
+
+// Login waits for a login prompt and logs in, returning once the shell prompt shows up.
+// An already logged in console is left as is
+func (con *Console) Login(user, password string, timeout time.Duration) error {
+	// Wake the console up, the login prompt was likely printed before we connected
+	if err := con.Send("\n"); err != nil {
+		return err
+	}
+	out, err := con.Expect(regexp.MustCompile(loginPromptRegex.String()+"|"+shellPromptRegex.String()), timeout)
+	if err != nil {
+		return err
+	}
+	if shellPromptRegex.MatchString(out) {
+		return nil
+	}
+
+	if err := con.Send(user + "\n"); err != nil {
+		return err
+	}
+	if _, err := con.Expect(passwordPromptRegex, timeout); err != nil {
+		return err
+	}
+	if err := con.Send(password + "\n"); err != nil {
+		return err
+	}
+	if _, err := con.Expect(shellPromptRegex, timeout); err != nil {
+		return fmt.Errorf("login as %s failed: %v", user, err)
+	}
+	return nil
+}
+
+// RunCommand runs cmd in the logged in shell and returns its output and exit code
+func (con *Console) RunCommand(cmd string, timeout time.Duration) (string, int, error) {
+	// The echoed command line holds $? rather than digits, so it does not match the marker
+	if err := con.Send(fmt.Sprintf("%s; echo \"%s:$?:\"\n", cmd, exitCodeMarker)); err != nil {
+		return "", 0, err
+	}
+	out, err := con.Expect(exitCodeRegex, timeout)
+	if err != nil {
+		return out, 0, err
+	}
+	exitCode, err := strconv.Atoi(exitCodeRegex.FindStringSubmatch(out)[1])
+	if err != nil {
+		return out, 0, err
+	}
+	// Leave the console at a fresh prompt for the next command
+	if _, err := con.Expect(shellPromptRegex, timeout); err != nil {
+		return out, exitCode, err
+	}
+
+	// Drop the echoed command line and the marker, keeping only what the command printed
+	lines := strings.Split(strings.ReplaceAll(out, "\r\n", "\n"), "\n")
+	if len(lines) > 2 {
+		lines = lines[1 : len(lines)-1]
+	} else {
+		lines = nil
+	}
+	return strings.Join(lines, "\n"), exitCode, nil
+}
+
+func (con *Console) Close() error {
+	return con.conn.Close()
+}
@@ -0,0 +1,226 @@
+package vm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubev1 "kubevirt.io/api/core/v1"
+	poolv1alpha1 "kubevirt.io/api/pool/v1alpha1"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	// Label put on every VM and VMI of a density group, so they can be listed and waited for together
+	DensityGroupLabel string = "node-e2e/density-group"
+	poolAPIVersion    string = "pool.kubevirt.io/v1alpha1"
+	poolKind          string = "VirtualMachinePool"
+)
+
+// Startup latencies of a single VM, each measured from the VM's creation
+type StartupLatency struct {
+	VMName    string
+	NodeName  string
+	Scheduled time.Duration
+	Running   time.Duration
+	Ready     time.Duration
+}
+
+// Percentiles of one startup stage across a density group
+type LatencySummary struct {
+	P50 time.Duration
+	P90 time.Duration
+	P99 time.Duration
+	Max time.Duration
+}
+
+// GenerateVirtualMachines generates count VMs named <VMName>-<index> from the same description,
+// all labeled with the DensityGroupLabel set to VMName
+func GenerateVirtualMachines(v VM, count int) ([]*kubev1.VirtualMachine, error) {
+	var vms []*kubev1.VirtualMachine
+
+	for i := 1; i <= count; i++ {
+		member := v
+		member.VMName = fmt.Sprintf("%s-%d", v.VMName, i)
+		member.Labels = withDensityGroupLabel(v.Labels, v.VMName)
+		member.VMSpec.VMISpec.Labels = withDensityGroupLabel(v.VMSpec.VMISpec.Labels, v.VMName)
+
+		vm, err := GenerateVirtualMachine(member)
+		if err != nil {
+			return nil, err
+		}
+		vms = append(vms, vm)
+	}
+	return vms, nil
+}
+
+// GenerateVirtualMachinePool generates a VirtualMachinePool named after VMName whose VMs follow the description.
+// KubeVirt names the pool's VMs and their DataVolumes <VMName>-<index>
+func GenerateVirtualMachinePool(v VM, replicas int32) (*poolv1alpha1.VirtualMachinePool, error) {
+	v.Labels = withDensityGroupLabel(v.Labels, v.VMName)
+	v.VMSpec.VMISpec.Labels = withDensityGroupLabel(v.VMSpec.VMISpec.Labels, v.VMName)
+
+	vm, err := GenerateVirtualMachine(v)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := poolv1alpha1.VirtualMachinePool{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       poolKind,
+			APIVersion: poolAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      v.VMName,
+			Namespace: v.Namespace,
+		},
+		Spec: poolv1alpha1.VirtualMachinePoolSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{DensityGroupLabel: v.VMName},
+			},
+			VirtualMachineTemplate: &poolv1alpha1.VirtualMachineTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Labels:      vm.Labels,
+					Annotations: vm.Annotations,
+				},
+				Spec: vm.Spec,
+			},
+		},
+	}
+	return &pool, nil
+}
+
+// Returns a copy, the caller's labels are left as is
+func withDensityGroupLabel(labels map[string]string, group string) map[string]string {
+	merged := make(map[string]string, len(labels)+1)
+	for k, v := range labels {
+		merged[k] = v
+	}
+	merged[DensityGroupLabel] = group
+	return merged
+}
+
+// CreateVirtualMachines creates the VMs with at most concurrency creations in flight.
+// All VMs are attempted and the errors of the failed ones are returned joined
+func CreateVirtualMachines(vms []*kubev1.VirtualMachine, concurrency int) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		var errs []error
+
+		if concurrency < 1 {
+			concurrency = 1
+		}
+		sem := make(chan struct{}, concurrency)
+
+		for _, vm := range vms {
+			wg.Add(1)
+			sem <- struct{}{}
+			go func(vm *kubev1.VirtualMachine) {
+				defer wg.Done()
+				defer func() { <-sem }()
+
+				if err := c.Client().Resources(vm.Namespace).Create(ctx, vm); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("failed to create VirtualMachine %s: %v", vm.Name, err))
+					mu.Unlock()
+				}
+			}(vm)
+		}
+		wg.Wait()
+
+		return errors.Join(errs...)
+	}
+}
+
+// GetStartupLatencies returns the startup latencies of every VM in the density group, using the
+// timestamps the API server and KubeVirt recorded so the polling interval does not skew them.
+// VMs which did not reach a stage yet report 0 for it
+func GetStartupLatencies(group, ns string) func(ctx context.Context, c *envconf.Config) ([]StartupLatency, error) {
+	return func(ctx context.Context, c *envconf.Config) ([]StartupLatency, error) {
+		var vmList kubev1.VirtualMachineList
+		var vmiList kubev1.VirtualMachineInstanceList
+		selector := resources.WithLabelSelector(fmt.Sprintf("%s=%s", DensityGroupLabel, group))
+
+		if err := c.Client().Resources(ns).List(ctx, &vmList, selector); err != nil {
+			return nil, fmt.Errorf("failed to list VirtualMachines of density group %s: %v", group, err)
+		}
+		if err := c.Client().Resources(ns).List(ctx, &vmiList, selector); err != nil {
+			return nil, fmt.Errorf("failed to list VirtualMachineInstances of density group %s: %v", group, err)
+		}
+
+		vmis := make(map[string]kubev1.VirtualMachineInstance, len(vmiList.Items))
+		for _, vmi := range vmiList.Items {
+			vmis[vmi.Name] = vmi
+		}
+
+		var latencies []StartupLatency
+		for _, vm := range vmList.Items {
+			created := vm.CreationTimestamp.Time
+			latency := StartupLatency{VMName: vm.Name}
+
+			vmi, ok := vmis[vm.Name]
+			if ok {
+				latency.NodeName = vmi.Status.NodeName
+				for _, transition := range vmi.Status.PhaseTransitionTimestamps {
+					switch transition.Phase {
+					case kubev1.Scheduled:
+						latency.Scheduled = transition.PhaseTransitionTimestamp.Sub(created)
+					case kubev1.Running:
+						latency.Running = transition.PhaseTransitionTimestamp.Sub(created)
+					}
+				}
+				for _, cond := range vmi.Status.Conditions {
+					if cond.Type == kubev1.VirtualMachineInstanceReady && cond.Status == corev1.ConditionTrue {
+						latency.Ready = cond.LastTransitionTime.Sub(created)
+					}
+				}
+			}
+			latencies = append(latencies, latency)
+		}
+		return latencies, nil
+	}
+}
+
+// SummarizeLatencies returns the percentiles of one stage, e.g. func(l StartupLatency) time.Duration { return l.Ready }.
+// VMs which did not reach the stage are left out
+func SummarizeLatencies(latencies []StartupLatency, stage func(StartupLatency) time.Duration) LatencySummary {
+	var durations []time.Duration
+	for _, l := range latencies {
+		if d := stage(l); d > 0 {
+			durations = append(durations, d)
+		}
+	}
+	if len(durations) == 0 {
+		return LatencySummary{}
+	}
+	slices.Sort(durations)
+
+	return LatencySummary{
+		P50: percentile(durations, 50),
+		P90: percentile(durations, 90),
+		P99: percentile(durations, 99),
+		Max: durations[len(durations)-1],
+	}
+}
+
+// Nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func (s LatencySummary) String() string {
+	return fmt.Sprintf("p50=%s p90=%s p99=%s max=%s", s.P50, s.P90, s.P99, s.Max)
+}
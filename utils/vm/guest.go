@@ -0,0 +1,154 @@
+package vm
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	kubev1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	subresourcesPath string = "/apis/subresources.kubevirt.io/v1"
+	sshBannerPrefix  string = "SSH-"
+)
+
+// Returns what the guest agent reports about the guest, e.g. its hostname, OS and users
+func GetGuestAgentInfo(vmiName, ns string) func(ctx context.Context, c *envconf.Config) (*kubev1.VirtualMachineInstanceGuestAgentInfo, error) {
+	return func(ctx context.Context, c *envconf.Config) (*kubev1.VirtualMachineInstanceGuestAgentInfo, error) {
+		var info kubev1.VirtualMachineInstanceGuestAgentInfo
+		if err := getVMISubresource(ctx, c, vmiName, ns, "guestosinfo", &info); err != nil {
+			return nil, err
+		}
+		return &info, nil
+	}
+}
+
+// Returns the filesystems the guest agent reports as mounted in the guest
+func GetGuestFilesystems(vmiName, ns string) func(ctx context.Context, c *envconf.Config) (*kubev1.VirtualMachineInstanceFileSystemList, error) {
+	return func(ctx context.Context, c *envconf.Config) (*kubev1.VirtualMachineInstanceFileSystemList, error) {
+		var fsList kubev1.VirtualMachineInstanceFileSystemList
+		if err := getVMISubresource(ctx, c, vmiName, ns, "filesystemlist", &fsList); err != nil {
+			return nil, err
+		}
+		return &fsList, nil
+	}
+}
+
+// Reports true once every mount point is listed by the guest agent. Errors are treated as not yet
+// mounted, the guest agent subresources fail until the agent connects
+func GuestFilesystemsMounted(vmiName, ns string, mountPoints ...string) func(ctx context.Context, c *envconf.Config) (bool, error) {
+	return func(ctx context.Context, c *envconf.Config) (bool, error) {
+		fsList, err := GetGuestFilesystems(vmiName, ns)(ctx, c)
+		if err != nil {
+			return false, nil
+		}
+
+		var mounted []string
+		for _, fs := range fsList.Items {
+			mounted = append(mounted, fs.MountPoint)
+		}
+		for _, mountPoint := range mountPoints {
+			if !slices.Contains(mounted, mountPoint) {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+}
+
+// Reports true once an SSH server answers on address, in the host:port format, with its banner.
+// The address must be reachable from where the tests run, e.g. a NodePort or LoadBalancer Service,
+// or the VMI IP when running inside the cluster
+func SSHReachable(address string, dialTimeout time.Duration) func(ctx context.Context) (bool, error) {
+	return func(ctx context.Context) (bool, error) {
+		dialer := net.Dialer{Timeout: dialTimeout}
+		conn, err := dialer.DialContext(ctx, "tcp", address)
+		if err != nil {
+			return false, nil
+		}
+		defer conn.Close()
+
+		if err := conn.SetReadDeadline(time.Now().Add(dialTimeout)); err != nil {
+			return false, nil
+		}
+		banner, err := bufio.NewReader(conn).ReadString('\n')
+		if err != nil {
+			return false, nil
+		}
+		return strings.HasPrefix(banner, sshBannerPrefix), nil
+	}
+}
+
+// WaitForGuestAgent waits until the guest agent is connected, reports the expected hostname and
+// an interface with the expected IP. Empty hostname or ip match any value.
+// Unlike VMI Ready, which only means virt-launcher is ready, this means the guest OS booted
+func WaitForGuestAgent(vmiName, ns, hostname, ip string, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		vmi := &kubev1.VirtualMachineInstance{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      vmiName,
+				Namespace: ns,
+			},
+		}
+		deadline := time.Now().Add(timeout)
+
+		agentConnected := vmconditions.VMIAgentConnected()
+		ipReported := vmconditions.VMIInterfaceIPMatch("", ip)
+		if err := wait.For(conditions.New(c.Client().Resources(ns)).ResourceMatch(vmi, func(obj k8s.Object) bool { return agentConnected(obj) && ipReported(obj) }),
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval)); err != nil {
+			return fmt.Errorf("guest agent of %s did not connect and report IP %q: %v", vmiName, ip, err)
+		}
+
+		if hostname == "" {
+			return nil
+		}
+		var reported string
+		if err := wait.For(func(ctx context.Context) (bool, error) {
+			info, err := GetGuestAgentInfo(vmiName, ns)(ctx, c)
+			if err != nil {
+				return false, nil
+			}
+			reported = info.Hostname
+			return reported == hostname, nil
+		},
+			wait.WithTimeout(time.Until(deadline)),
+			wait.WithInterval(interval)); err != nil {
+			return fmt.Errorf("guest agent of %s hostname not as expected: expected %s, got %q: %v", vmiName, hostname, reported, err)
+		}
+		return nil
+	}
+}
+
+// Guest agent data is served by virt-api through subresources rather than the VMI status
+func getVMISubresource(ctx context.Context, c *envconf.Config, vmiName, ns, subresource string, into interface{}) error {
+	clientset, err := kubernetes.NewForConfig(c.Client().RESTConfig())
+	if err != nil {
+		return err
+	}
+
+	data, err := clientset.CoreV1().RESTClient().Get().
+		AbsPath(subresourcesPath, "namespaces", ns, "virtualmachineinstances", vmiName, subresource).
+		DoRaw(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get %s of VirtualMachineInstance %s: %v", subresource, vmiName, err)
+	}
+	if err := json.Unmarshal(data, into); err != nil {
+		return fmt.Errorf("failed to decode %s of VirtualMachineInstance %s: %v", subresource, vmiName, err)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package vm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	vmconditions "node-e2e/utils/conditions"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/kubernetes"
+	kubev1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/wait"
+	"sigs.k8s.io/e2e-framework/klient/wait/conditions"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+// AddVolume hotplugs a DataVolume or PVC volume into the running VMI of vmName. With persist the volume
+// is added through the VM so it is kept across restarts, otherwise only the running VMI gets it.
+// The disk bus defaults to scsi, the bus KubeVirt hotplugs disks on
+func AddVolume(vmName, ns string, vol Volume, persist bool) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		vol.Source.Hotpluggable = true
+		if errs := validateHotplugVolume(vol, kubev1.DiskBusSCSI, field.NewPath("Volume")); len(errs) > 0 {
+			return fmt.Errorf("invalid hotplug volume %s: %v", vol.Name, errs.ToAggregate())
+		}
+
+		disk := generateDisk(vol, VMDomainSpec{DiskBus: kubev1.DiskBusSCSI})
+		opts := kubev1.AddVolumeOptions{
+			Name:         vol.Name,
+			Disk:         &disk,
+			VolumeSource: generateHotplugVolumeSource(vol),
+		}
+		return putVolumeSubresource(ctx, c, vmName, ns, "addvolume", persist, opts)
+	}
+}
+
+// RemoveVolume unplugs a hotplugged volume. persist has to match the one the volume was added with
+func RemoveVolume(vmName, ns, volumeName string, persist bool) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		opts := kubev1.RemoveVolumeOptions{
+			Name: volumeName,
+		}
+		return putVolumeSubresource(ctx, c, vmName, ns, "removevolume", persist, opts)
+	}
+}
+
+// HotplugVolume adds the volume and waits until the VMI reports it Ready, i.e. attached to the guest
+func HotplugVolume(vmName, ns string, vol Volume, persist bool, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		if err := AddVolume(vmName, ns, vol, persist)(ctx, c); err != nil {
+			return err
+		}
+		if err := wait.For(conditions.New(c.Client().Resources(ns)).ResourceMatch(genVMIObject(vmName, ns), vmconditions.VMIVolumeReady(vol.Name)),
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval)); err != nil {
+			return fmt.Errorf("volume %s of VirtualMachineInstance %s did not become Ready: %v", vol.Name, vmName, err)
+		}
+		return nil
+	}
+}
+
+// UnplugVolume removes the volume and waits until the VMI no longer reports it
+func UnplugVolume(vmName, ns, volumeName string, persist bool, timeout, interval time.Duration) func(ctx context.Context, c *envconf.Config) error {
+	return func(ctx context.Context, c *envconf.Config) error {
+		if err := RemoveVolume(vmName, ns, volumeName, persist)(ctx, c); err != nil {
+			return err
+		}
+		if err := wait.For(conditions.New(c.Client().Resources(ns)).ResourceMatch(genVMIObject(vmName, ns), vmconditions.VMIVolumeRemoved(volumeName)),
+			wait.WithTimeout(timeout),
+			wait.WithInterval(interval)); err != nil {
+			return fmt.Errorf("volume %s of VirtualMachineInstance %s was not removed: %v", volumeName, vmName, err)
+		}
+		return nil
+	}
+}
+
+func generateHotplugVolumeSource(vol Volume) *kubev1.HotplugVolumeSource {
+	sourceName := volumeSourceName(vol)
+	if vol.Type == PersistentVolumeClaim {
+		return &kubev1.HotplugVolumeSource{
+			PersistentVolumeClaim: &kubev1.PersistentVolumeClaimVolumeSource{
+				PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: sourceName,
+					ReadOnly:  vol.Disk.ReadOnly,
+				},
+				Hotpluggable: true,
+			},
+		}
+	}
+	return &kubev1.HotplugVolumeSource{
+		DataVolume: &kubev1.DataVolumeSource{
+			Name:         sourceName,
+			Hotpluggable: true,
+		},
+	}
+}
+
+// Volume hotplug is served by virt-api through subresources of both the VM and the VMI
+func putVolumeSubresource(ctx context.Context, c *envconf.Config, vmName, ns, subresource string, persist bool, opts interface{}) error {
+	clientset, err := kubernetes.NewForConfig(c.Client().RESTConfig())
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(opts)
+	if err != nil {
+		return err
+	}
+
+	resource := "virtualmachineinstances"
+	if persist {
+		resource = "virtualmachines"
+	}
+	if _, err := clientset.CoreV1().RESTClient().Put().
+		AbsPath(subresourcesPath, "namespaces", ns, resource, vmName, subresource).
+		SetHeader("Content-Type", "application/json").
+		Body(body).
+		DoRaw(ctx); err != nil {
+		return fmt.Errorf("failed to call %s of %s %s: %v", subresource, resource, vmName, err)
+	}
+	return nil
+}
+
+func genVMIObject(name, ns string) *kubev1.VirtualMachineInstance {
+	return &kubev1.VirtualMachineInstance{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+	}
+}
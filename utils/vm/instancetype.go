@@ -0,0 +1,135 @@
+package vm
+
+import (
+	"node-e2e/utils"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubev1 "kubevirt.io/api/core/v1"
+	instancetypev1beta1 "kubevirt.io/api/instancetype/v1beta1"
+)
+
+const (
+	instancetypeAPIVersion string = "instancetype.kubevirt.io/v1beta1"
+)
+
+// Namespaced instancetypes are preferred in tests as they are removed together with the test's namespace
+func GenerateVirtualMachineInstancetype(name, ns string, spec InstancetypeSpec) *instancetypev1beta1.VirtualMachineInstancetype {
+	it := instancetypev1beta1.VirtualMachineInstancetype{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       InstancetypeKind,
+			APIVersion: instancetypeAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: *generateInstancetypeSpec(spec),
+	}
+	return &it
+}
+
+func GenerateVirtualMachineClusterInstancetype(name string, spec InstancetypeSpec) *instancetypev1beta1.VirtualMachineClusterInstancetype {
+	it := instancetypev1beta1.VirtualMachineClusterInstancetype{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       ClusterInstancetypeKind,
+			APIVersion: instancetypeAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: *generateInstancetypeSpec(spec),
+	}
+	return &it
+}
+
+func GenerateVirtualMachinePreference(name, ns string, spec PreferenceSpec) *instancetypev1beta1.VirtualMachinePreference {
+	pref := instancetypev1beta1.VirtualMachinePreference{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       PreferenceKind,
+			APIVersion: instancetypeAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: ns,
+		},
+		Spec: *generatePreferenceSpec(spec),
+	}
+	return &pref
+}
+
+func GenerateVirtualMachineClusterPreference(name string, spec PreferenceSpec) *instancetypev1beta1.VirtualMachineClusterPreference {
+	pref := instancetypev1beta1.VirtualMachineClusterPreference{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       ClusterPreferenceKind,
+			APIVersion: instancetypeAPIVersion,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name: name,
+		},
+		Spec: *generatePreferenceSpec(spec),
+	}
+	return &pref
+}
+
+func generateInstancetypeSpec(spec InstancetypeSpec) *instancetypev1beta1.VirtualMachineInstancetypeSpec {
+	its := instancetypev1beta1.VirtualMachineInstancetypeSpec{
+		CPU: instancetypev1beta1.CPUInstancetype{
+			Guest: spec.CPUs,
+		},
+		Memory: instancetypev1beta1.MemoryInstancetype{
+			Guest: (*utils.GenerateResourceList("", spec.Memory, "", ""))[corev1.ResourceMemory],
+		},
+	}
+
+	if spec.DedicatedCPUPlacement {
+		its.CPU.DedicatedCPUPlacement = func(b bool) *bool { return &b }(true)
+	}
+	if spec.IsolateEmulatorThread {
+		its.CPU.IsolateEmulatorThread = func(b bool) *bool { return &b }(true)
+	}
+	if spec.HugepagesPageSize != "" {
+		its.Memory.Hugepages = &kubev1.Hugepages{
+			PageSize: spec.HugepagesPageSize,
+		}
+	}
+	return &its
+}
+
+// Only the preferences which were set are added, the rest is left for KubeVirt to default
+func generatePreferenceSpec(spec PreferenceSpec) *instancetypev1beta1.VirtualMachinePreferenceSpec {
+	var ps instancetypev1beta1.VirtualMachinePreferenceSpec
+
+	if spec.MachineType != "" {
+		ps.Machine = &instancetypev1beta1.MachinePreferences{
+			PreferredMachineType: spec.MachineType,
+		}
+	}
+	if spec.DiskBus != "" || spec.InterfaceModel != "" {
+		ps.Devices = &instancetypev1beta1.DevicePreferences{
+			PreferredDiskBus:        spec.DiskBus,
+			PreferredInterfaceModel: spec.InterfaceModel,
+		}
+	}
+	if spec.CPUTopology != "" {
+		topology := instancetypev1beta1.PreferredCPUTopology(spec.CPUTopology)
+		ps.CPU = &instancetypev1beta1.CPUPreferences{
+			PreferredCPUTopology: &topology,
+		}
+	}
+	if spec.EFI {
+		ps.Firmware = &instancetypev1beta1.FirmwarePreferences{
+			PreferredUseEfi:        func(b bool) *bool { return &b }(true),
+			PreferredUseSecureBoot: func(b bool) *bool { return &b }(spec.SecureBoot),
+		}
+		// SecureBoot requires SMM
+		if spec.SecureBoot {
+			ps.Features = &instancetypev1beta1.FeaturePreferences{
+				PreferredSmm: &kubev1.FeatureState{
+					Enabled: func(b bool) *bool { return &b }(true),
+				},
+			}
+		}
+	}
+	return &ps
+}
@@ -0,0 +1,43 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/klient/k8s/resources"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+)
+
+const (
+	virtLauncherLabelSelector string = "kubevirt.io=virt-launcher,vm.kubevirt.io/name=%s"
+)
+
+// Returns the virt-launcher pod running the VMI. Pods which already completed, e.g. the source of a
+// finished migration, are skipped
+func GetVirtLauncherPod(vmiName, ns string) func(ctx context.Context, c *envconf.Config) (*corev1.Pod, error) {
+	return func(ctx context.Context, c *envconf.Config) (*corev1.Pod, error) {
+		var podList corev1.PodList
+
+		if err := c.Client().Resources(ns).List(ctx, &podList, resources.WithLabelSelector(fmt.Sprintf(virtLauncherLabelSelector, vmiName))); err != nil {
+			return nil, fmt.Errorf("failed to list virt-launcher pods of %s: %v", vmiName, err)
+		}
+		for i := range podList.Items {
+			if phase := podList.Items[i].Status.Phase; phase != corev1.PodSucceeded && phase != corev1.PodFailed {
+				return &podList.Items[i], nil
+			}
+		}
+		return nil, fmt.Errorf("no running virt-launcher pod was found for %s", vmiName)
+	}
+}
+
+// Returns the QoS class Kubernetes assigned to the virt-launcher pod running the VMI
+func GetVirtLauncherQOSClass(vmiName, ns string) func(ctx context.Context, c *envconf.Config) (corev1.PodQOSClass, error) {
+	return func(ctx context.Context, c *envconf.Config) (corev1.PodQOSClass, error) {
+		pod, err := GetVirtLauncherPod(vmiName, ns)(ctx, c)
+		if err != nil {
+			return "", err
+		}
+		return pod.Status.QOSClass, nil
+	}
+}
@@ -0,0 +1,95 @@
+package vm
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"node-e2e/utils/template"
+
+	kubev1 "kubevirt.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// LoadVM reads a VM description from a YAML file, e.g. one under a suite's testdata directory.
+// The file is rendered with the template package first, so it may hold placeholders such as {{.vmName}}.
+// Keys are the Go field names matched case insensitively, e.g. vmName or VMName, and unknown keys are rejected.
+func LoadVM(path string, values map[string]interface{}) (*VM, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read VM description %s: %v", path, err)
+	}
+
+	rendered, err := template.Template(values, data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to template VM description %s: %v", path, err)
+	}
+	renderedData, err := io.ReadAll(rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	var v VM
+	if err := yaml.UnmarshalStrict(renderedData, &v); err != nil {
+		return nil, fmt.Errorf("failed to decode VM description %s: %v", path, err)
+	}
+	return &v, nil
+}
+
+// DumpVirtualMachine returns the YAML manifest of a generated VirtualMachine
+func DumpVirtualMachine(vm *kubev1.VirtualMachine) ([]byte, error) {
+	data, err := yaml.Marshal(vm)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode VirtualMachine %s: %v", vm.GetName(), err)
+	}
+	return data, nil
+}
+
+// WriteVirtualMachine writes the YAML manifest of a generated VirtualMachine to path for review
+func WriteVirtualMachine(vm *kubev1.VirtualMachine, path string) error {
+	data, err := DumpVirtualMachine(vm)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// MatchGolden compares the YAML manifest of a generated VirtualMachine with a golden file and
+// returns an error pointing at the first line which differs. With update set, the golden file is
+// rewritten instead. The VM should set VMISpec.CloudInitPassword, otherwise a random password
+// makes every generation differ.
+func MatchGolden(vm *kubev1.VirtualMachine, goldenPath string, update bool) error {
+	if update {
+		return WriteVirtualMachine(vm, goldenPath)
+	}
+
+	data, err := DumpVirtualMachine(vm)
+	if err != nil {
+		return err
+	}
+	golden, err := os.ReadFile(goldenPath)
+	if err != nil {
+		return fmt.Errorf("failed to read golden file %s: %v", goldenPath, err)
+	}
+	if bytes.Equal(data, golden) {
+		return nil
+	}
+
+	generatedLines := strings.Split(string(data), "\n")
+	goldenLines := strings.Split(string(golden), "\n")
+	for i := 0; i < len(generatedLines) || i < len(goldenLines); i++ {
+		var generatedLine, goldenLine string
+		if i < len(generatedLines) {
+			generatedLine = generatedLines[i]
+		}
+		if i < len(goldenLines) {
+			goldenLine = goldenLines[i]
+		}
+		if generatedLine != goldenLine {
+			return fmt.Errorf("VirtualMachine %s does not match golden file %s at line %d: expected %q, got %q", vm.GetName(), goldenPath, i+1, goldenLine, generatedLine)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package vm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+const (
+	// The scenarios booted by the vm_scenarios suite, each has a golden manifest under goldenDir
+	scenariosDir string = "../../e2e/vm_scenarios/testdata/scenarios"
+	goldenDir    string = "testdata/golden"
+	// Set to true to rewrite the golden files from the current generation
+	updateGoldenEnv string = "UPDATE_GOLDEN"
+)
+
+func TestMatchGolden(t *testing.T) {
+	scenarios, err := filepath.Glob(filepath.Join(scenariosDir, "*.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenarios) == 0 {
+		t.Fatalf("no scenarios found in %s", scenariosDir)
+	}
+
+	feat := features.New("VM golden manifests").
+		WithLabel("type", "VM").
+		Assess("Test generating the VirtualMachine of each scenario and comparing it with its golden manifest", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, scenario := range scenarios {
+				scenarioName := strings.TrimSuffix(filepath.Base(scenario), filepath.Ext(scenario))
+				t.Run(scenarioName, func(t *testing.T) {
+					// Fixed values keep the generated manifest reproducible
+					v, err := LoadVM(scenario, map[string]interface{}{
+						"vmName":       scenarioName,
+						"namespace":    "default",
+						"osImagePVC":   "rhel7-9-az-a",
+						"storageClass": "az-a",
+						"password":     "golden",
+					})
+					if err != nil {
+						t.Fatal(err)
+					}
+					// Stand in for dv.ResolveStorage, which needs the StorageProfile of a cluster
+					for i := range v.VMSpec.DataVolumes {
+						if len(v.VMSpec.DataVolumes[i].PVAccessModes) == 0 {
+							v.VMSpec.DataVolumes[i].PVAccessModes = []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany}
+						}
+						if v.VMSpec.DataVolumes[i].PVMode == "" {
+							v.VMSpec.DataVolumes[i].PVMode = corev1.PersistentVolumeBlock
+						}
+					}
+
+					goldenVM, err := GenerateVirtualMachine(*v)
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					goldenPath := filepath.Join(goldenDir, scenarioName+".yaml")
+					if err := MatchGolden(goldenVM, goldenPath, os.Getenv(updateGoldenEnv) == "true"); err != nil {
+						t.Fatal(err)
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
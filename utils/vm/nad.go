@@ -0,0 +1,79 @@
+package vm
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	nadAPIVersion          string = "k8s.cni.cncf.io/v1"
+	nadKind                string = "NetworkAttachmentDefinition"
+	nadResourceAnnotation  string = "k8s.v1.cni.cncf.io/resourceName"
+	cniVersion             string = "0.3.1"
+	defaultBridgeCNIPlugin string = "bridge"
+	defaultSRIOVCNIPlugin  string = "sriov"
+)
+
+// The NetworkAttachmentDefinition API is not vendored, so the object is generated as unstructured.
+// It can be created and deleted with the e2e-framework client like any typed object.
+func GenerateNetworkAttachmentDefinition(n NAD) (*unstructured.Unstructured, error) {
+	config, err := generateCNIConfig(n)
+	if err != nil {
+		return nil, err
+	}
+
+	nad := &unstructured.Unstructured{}
+	nad.SetAPIVersion(nadAPIVersion)
+	nad.SetKind(nadKind)
+	nad.SetName(n.Name)
+	nad.SetNamespace(n.Namespace)
+	// The SR-IOV device plugin resource is requested by Multus according to this annotation
+	if n.Type == SRIOVNetwork {
+		nad.SetAnnotations(map[string]string{nadResourceAnnotation: n.ResourceName})
+	}
+	if err := unstructured.SetNestedField(nad.Object, config, "spec", "config"); err != nil {
+		return nil, fmt.Errorf("failed to set NetworkAttachmentDefinition %s config: %v", n.Name, err)
+	}
+	return nad, nil
+}
+
+func generateCNIConfig(n NAD) (string, error) {
+	config := map[string]interface{}{
+		"cniVersion": cniVersion,
+		"name":       n.Name,
+	}
+
+	switch n.Type {
+	case BridgeNetwork:
+		config["type"] = cniPluginOrDefault(n.CNIType, defaultBridgeCNIPlugin)
+		config["bridge"] = n.Bridge
+		if n.MacSpoofCheck {
+			config["macspoofchk"] = true
+		}
+	case SRIOVNetwork:
+		config["type"] = cniPluginOrDefault(n.CNIType, defaultSRIOVCNIPlugin)
+	default:
+		return "", fmt.Errorf("unsupported NetworkAttachmentDefinition type %q, expected %s or %s", n.Type, BridgeNetwork, SRIOVNetwork)
+	}
+	if n.VLAN != 0 {
+		config["vlan"] = n.VLAN
+	}
+	if n.MTU != 0 {
+		config["mtu"] = n.MTU
+	}
+
+	data, err := json.Marshal(config)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal NetworkAttachmentDefinition %s config: %v", n.Name, err)
+	}
+	return string(data), nil
+}
+
+func cniPluginOrDefault(plugin, def string) string {
+	if plugin != "" {
+		return plugin
+	}
+	return def
+}
@@ -10,13 +10,81 @@ import (
 type VMDomainSpec struct {
 	RequestsCPU    string
 	RequestsMemory string
-	Cores          uint32
-	Sockets        uint32
-	Threads        uint32
-	disks          []kubev1.Disk
-	interfaces     []kubev1.Interface
+	// Explicit limits take precedence over the ones derived from LimitPolicy
+	LimitsCPU    string
+	LimitsMemory string
+	// Defaults to LimitPolicyRatio
+	LimitPolicy LimitPolicy
+	// CPU limits to requests ratio used by LimitPolicyRatio, defaults to 4
+	CPULimitsRatio int64
+	// Memory visible to the guest, defaults to the memory requests
+	GuestMemory string
+	// Derive the memory requests from GuestMemory, e.g. 150 requests two thirds of the guest memory.
+	// Ignored when RequestsMemory is set
+	MemoryOvercommitPercent int64
+	// Derive the CPU requests from the vCPU count, e.g. 1000 requests a tenth of a CPU per vCPU.
+	// Ignored when RequestsCPU is set
+	CPUOvercommitPercent int64
+	// Do not add the virt-launcher overhead to the memory requests
+	OvercommitGuestOverhead bool
+	// vCPU topology
+	Cores   uint32
+	Sockets uint32
+	Threads uint32
+	// QEMU machine type, defaults to pc-q35-rhel8.6.0
+	MachineType string
+	// Nil keeps the KubeVirt default which is BIOS
+	Firmware *Firmware
+	// System Management Mode, required by EFI SecureBoot
+	SMM bool
+	// Bus used by disk and LUN devices which do not set one, defaults to virtio
+	DiskBus kubev1.DiskBus
+	// Both default to true when nil
+	NetworkInterfaceMultiQueue *bool
+	Rng                        *bool
+	// Back the guest memory with hugepages of the given size, e.g. 2Mi or 1Gi
+	HugepagesPageSize string
+	// Pin vCPUs to dedicated host CPUs, requires whole CPU requests
+	DedicatedCPUPlacement bool
+	// Requires DedicatedCPUPlacement
+	IsolateEmulatorThread bool
+	// Requires DedicatedCPUPlacement and HugepagesPageSize
+	NUMAGuestMappingPassthrough bool
+	disks                       []kubev1.Disk
+	interfaces                  []kubev1.Interface
+	// Set when a preference is referenced, so defaults are left for the preference to fill in
+	withPreference bool
 }
 
+// LimitPolicy decides how limits are derived when they are not set explicitly
+type LimitPolicy string
+
+const (
+	// CPU limits are CPULimitsRatio times the CPU requests, memory is not limited, resulting in a Burstable pod
+	LimitPolicyRatio LimitPolicy = "Ratio"
+	// No limits are set, resulting in a Burstable pod
+	LimitPolicyNone LimitPolicy = "None"
+	// Limits equal requests for both CPU and memory, resulting in a Guaranteed pod
+	LimitPolicyGuaranteed LimitPolicy = "Guaranteed"
+)
+
+type Firmware struct {
+	Type FirmwareType
+	// EFI only, requires VMDomainSpec.SMM
+	SecureBoot bool
+	// EFI only, persist the NVRAM across reboots
+	Persistent bool
+	// BIOS only, transmit the BIOS output over serial
+	UseSerial bool
+}
+
+type FirmwareType string
+
+const (
+	BIOSFirmware FirmwareType = "BIOS"
+	EFIFirmware  FirmwareType = "EFI"
+)
+
 type VMISpec struct {
 	VMDomainSpec VMDomainSpec
 	Labels       map[string]string
@@ -36,18 +104,69 @@ type VMISpec struct {
 	LivenessProbe *kubev1.Probe
 	// Should create a generate func if not a complex struct
 	ReadinessProbe *kubev1.Probe
-	// Allowing only DataVolume, CloudInitNoCloud
-	// - GenerateVolume
-	// - GenerateCloudInitNoCloudVolume
+	// A cloud-init NoCloud volume named cloudinit is added on top of these, unless one is defined here
 	Volumes  []Volume
 	Networks []Network
+	// Passed to the default cloud-init NoCloud volume. A fixed password keeps the generated VM reproducible
+	CloudInitPassword string
+	SSHAuthorizedKey  string
 }
 
 type VMSpec struct {
 	VMISpec     VMISpec
 	Running     bool
 	RunStrategy kubev1.VirtualMachineRunStrategy
+	// Shorthand for DataVolume volumes named <vmname>-<index> and booted in order, starting at 1.
+	// Define VMISpec.Volumes with a DataVolumeTemplate source to choose names and boot order
 	DataVolumes []dv.DataVolumeData
+	// Size the VM through an instancetype instead of the VMDomainSpec CPU and memory fields
+	Instancetype *InstancetypeRef
+	// Domain settings left empty, e.g. machine type and disk bus, are taken from the preference
+	Preference *PreferenceRef
+}
+
+type InstancetypeRef struct {
+	Name string
+	// Defaults to ClusterInstancetypeKind
+	Kind string
+	// Name of a volume whose labels point to the instancetype, used instead of Name
+	InferFromVolume string
+}
+
+type PreferenceRef struct {
+	Name string
+	// Defaults to ClusterPreferenceKind
+	Kind string
+	// Name of a volume whose labels point to the preference, used instead of Name
+	InferFromVolume string
+}
+
+const (
+	InstancetypeKind        string = "VirtualMachineInstancetype"
+	ClusterInstancetypeKind string = "VirtualMachineClusterInstancetype"
+	PreferenceKind          string = "VirtualMachinePreference"
+	ClusterPreferenceKind   string = "VirtualMachineClusterPreference"
+)
+
+// InstancetypeSpec describes the sizing of a test-scoped instancetype
+type InstancetypeSpec struct {
+	CPUs                  uint32
+	Memory                string
+	DedicatedCPUPlacement bool
+	IsolateEmulatorThread bool
+	// e.g. 2Mi or 1Gi
+	HugepagesPageSize string
+}
+
+// PreferenceSpec describes the defaults a test-scoped preference applies to a VM
+type PreferenceSpec struct {
+	MachineType    string
+	DiskBus        kubev1.DiskBus
+	InterfaceModel string
+	// e.g. preferSockets or preferCores
+	CPUTopology string
+	EFI         bool
+	SecureBoot  bool
 }
 
 type VM struct {
@@ -59,27 +178,160 @@ type VM struct {
 }
 
 type Network struct {
-	Name    string
-	Type    NetworkType
+	Name string
+	// Defaults to MasqueradeNetwork
+	Type NetworkType
+	// NetworkAttachmentDefinition, required by Bridge and SRIOV networks
 	NADName *string
+	// Namespace of the NetworkAttachmentDefinition, defaults to the VM namespace
+	NADNamespace string
+	// Name of the binding plugin as registered in the KubeVirt CR, defaults to the Passt or Slirp type in lower case
+	BindingName string
+	// Interface model, defaults to virtio. Not used by SRIOV which passes a VF through
+	Model InterfaceModel
+	// Generated by KubeVirt when empty
+	MacAddress string
+	// Guest PCI address in the <domain>:<bus>:<slot>.<function> format, e.g. 0000:81:01.0
+	PciAddress string
 }
 
 type NetworkType string
 
 const (
-	BridgeNetwork     NetworkType = "Bridge"
+	// Multus secondary network bound with a Linux bridge
+	BridgeNetwork NetworkType = "Bridge"
+	// Pod network
 	MasqueradeNetwork NetworkType = "Masquerade"
+	// Multus secondary network passing an SR-IOV VF through to the guest
+	SRIOVNetwork NetworkType = "SRIOV"
+	// Pod network bound with the passt binding plugin
+	PasstNetwork NetworkType = "Passt"
+	// Pod network bound with the slirp binding plugin
+	SlirpNetwork NetworkType = "Slirp"
 )
 
-type Volume struct {
+type InterfaceModel string
+
+const (
+	InterfaceModelVirtio  InterfaceModel = "virtio"
+	InterfaceModelE1000   InterfaceModel = "e1000"
+	InterfaceModelE1000E  InterfaceModel = "e1000e"
+	InterfaceModelIGB     InterfaceModel = "igb"
+	InterfaceModelRTL8139 InterfaceModel = "rtl8139"
+	InterfaceModelPCNet   InterfaceModel = "pcnet"
+	InterfaceModelNE2KPCI InterfaceModel = "ne2k_pci"
+)
+
+// NAD describes a NetworkAttachmentDefinition backing a Bridge or SRIOV network
+type NAD struct {
 	Name      string
-	Type      VolumeType
+	Namespace string
+	// BridgeNetwork or SRIOVNetwork
+	Type NetworkType
+	// CNI plugin, defaults to bridge or sriov according to Type. OpenShift clusters may use cnv-bridge
+	CNIType string
+	// Bridge only, the node bridge to connect to
+	Bridge string
+	// SRIOV only, the device plugin resource VFs are allocated from, e.g. openshift.io/mlxnics
+	ResourceName string
+	// 0 leaves the traffic untagged
+	VLAN int
+	// 0 keeps the CNI plugin default
+	MTU int
+	// Bridge only, drop traffic whose source MAC is not the interface's
+	MacSpoofCheck bool
+}
+
+type Volume struct {
+	// Used as the disk name, must be a DNS label
+	Name string
+	Type VolumeType
+	// Lower boots first, starting at 1. Volumes without one are not booted from
 	Bootorder *uint
+	Disk      DiskOptions
+	Source    VolumeSourceOptions
 }
 
+// VolumeSourceOptions configure where a volume's data comes from. Only the fields
+// relevant to the volume's Type are used
+type VolumeSourceOptions struct {
+	// Name of the referenced DataVolume, PVC, Secret, ConfigMap or ServiceAccount.
+	// Defaults to the volume name
+	Name string
+	// DataVolume only, generate a DataVolume template named after the volume's source
+	// instead of referencing an existing DataVolume
+	DataVolumeTemplate *dv.DataVolumeData
+	// DataVolume and PersistentVolumeClaim only, attach the volume as a hotplugged disk.
+	// Adding such a volume to the spec of a running VM hotplugs it declaratively
+	Hotpluggable bool
+	// ContainerDisk image, e.g. quay.io/containerdisks/fedora:latest
+	Image           string
+	ImagePullPolicy corev1.PullPolicy
+	ImagePullSecret string
+	// EmptyDisk size, or the size of a HostDisk image to create if it does not exist
+	Capacity string
+	// HostDisk image path on the node
+	Path string
+	// CloudInitNoCloud cloud-user password, a random one is generated when empty
+	Password string
+	// CloudInitNoCloud SSH key authorized for cloud-user
+	SSHAuthorizedKey string
+}
+
+// DiskOptions control how a volume is presented to the guest. The zero value is a
+// read-write disk on the domain's default bus
+type DiskOptions struct {
+	// Defaults to DiskDeviceDisk
+	Device DiskDeviceType
+	// Defaults to VMDomainSpec.DiskBus for disks and LUNs and to sata for CD-ROMs
+	Bus      kubev1.DiskBus
+	ReadOnly bool
+	Serial   string
+	Cache    kubev1.DriverCache
+	IO       kubev1.DriverIO
+}
+
+type DiskDeviceType string
+
+const (
+	DiskDeviceDisk  DiskDeviceType = "Disk"
+	DiskDeviceCDRom DiskDeviceType = "CDRom"
+	DiskDeviceLUN   DiskDeviceType = "LUN"
+)
+
 type VolumeType string
 
 const (
-	DataVolume       VolumeType = "DataVolume"
-	CloudInitNoCloud VolumeType = "CloudInitNoCloud"
+	DataVolume            VolumeType = "DataVolume"
+	CloudInitNoCloud      VolumeType = "CloudInitNoCloud"
+	ContainerDisk         VolumeType = "ContainerDisk"
+	PersistentVolumeClaim VolumeType = "PersistentVolumeClaim"
+	// Copy-on-write layer over a PVC, writes are discarded when the VMI stops
+	Ephemeral       VolumeType = "Ephemeral"
+	EmptyDisk       VolumeType = "EmptyDisk"
+	HostDisk        VolumeType = "HostDisk"
+	Secret          VolumeType = "Secret"
+	ConfigMap       VolumeType = "ConfigMap"
+	ServiceAccount  VolumeType = "ServiceAccount"
+	DownwardMetrics VolumeType = "DownwardMetrics"
 )
+
+type VMClone struct {
+	CloneName string
+	Namespace string
+	// Name of the VirtualMachine to clone from
+	SourceVMName string
+	// Name of the VirtualMachine to be created. Left empty, KubeVirt generates a random name
+	// which can later be found at the clone's status.targetName
+	TargetVMName string
+	// Filters for the target VM's labels and annotations, e.g. "*" or "!some/key*"
+	LabelFilters      []string
+	AnnotationFilters []string
+	// Filters for the target VM's template (spec.template.metadata) labels and annotations
+	TemplateLabelFilters      []string
+	TemplateAnnotationFilters []string
+	// Interface name to MAC address. Interfaces not listed get a newly generated MAC address
+	NewMacAddresses map[string]string
+	// Nil lets KubeVirt generate a new SMBios serial for the target
+	NewSMBiosSerial *string
+}
@@ -0,0 +1,630 @@
+package vm
+
+import (
+	"fmt"
+	stdnet "net"
+	"regexp"
+	"slices"
+
+	"node-e2e/utils"
+	dv "node-e2e/utils/datavolume"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+)
+
+var (
+	supportedDiskBuses   = []string{string(kubev1.DiskBusVirtio), string(kubev1.DiskBusSATA), string(kubev1.DiskBusSCSI), string(kubev1.DiskBusUSB)}
+	supportedDiskDevices = []string{string(DiskDeviceDisk), string(DiskDeviceCDRom), string(DiskDeviceLUN)}
+	supportedCacheModes  = []string{string(kubev1.CacheNone), string(kubev1.CacheWriteThrough), string(kubev1.CacheWriteBack)}
+	supportedIOModes     = []string{string(kubev1.IONative), string(kubev1.IOThreads)}
+	supportedPageSizes   = []string{"2Mi", "1Gi"}
+	supportedAccessModes = []string{
+		string(corev1.ReadWriteOnce), string(corev1.ReadOnlyMany), string(corev1.ReadWriteMany), string(corev1.ReadWriteOncePod),
+	}
+	supportedVolumeModes = []string{string(corev1.PersistentVolumeBlock), string(corev1.PersistentVolumeFilesystem)}
+	supportedLimitPolicy = []string{string(LimitPolicyRatio), string(LimitPolicyNone), string(LimitPolicyGuaranteed)}
+	supportedVolumeTypes = []string{
+		string(DataVolume), string(CloudInitNoCloud), string(ContainerDisk), string(PersistentVolumeClaim), string(Ephemeral),
+		string(EmptyDisk), string(HostDisk), string(Secret), string(ConfigMap), string(ServiceAccount), string(DownwardMetrics),
+	}
+	supportedNetworkTypes = []string{
+		string(BridgeNetwork), string(MasqueradeNetwork), string(SRIOVNetwork), string(PasstNetwork), string(SlirpNetwork),
+	}
+	supportedInterfaceModels = []string{
+		string(InterfaceModelVirtio), string(InterfaceModelE1000), string(InterfaceModelE1000E), string(InterfaceModelIGB),
+		string(InterfaceModelRTL8139), string(InterfaceModelPCNet), string(InterfaceModelNE2KPCI),
+	}
+	// Same restriction KubeVirt puts on interface PCI addresses
+	pciAddressRegex = regexp.MustCompile(`^([\da-fA-F]{4}):([\da-fA-F]{2}):([\da-fA-F]{2})\.([0-7])$`)
+	// Same restriction KubeVirt puts on disk serial numbers
+	diskSerialRegex = regexp.MustCompile(`^[A-Za-z0-9_.+-]+$`)
+)
+
+// Validate aggregates every check of the VM description, so all problems are reported at once
+// with the path of the offending field
+func (v VM) Validate() field.ErrorList {
+	var allErrs field.ErrorList
+	vmPath := field.NewPath("VM")
+	specPath := vmPath.Child("VMSpec")
+	vmiPath := specPath.Child("VMISpec")
+
+	if v.VMName == "" {
+		allErrs = append(allErrs, field.Required(vmPath.Child("VMName"), ""))
+	}
+	if v.Namespace == "" {
+		allErrs = append(allErrs, field.Required(vmPath.Child("Namespace"), ""))
+	}
+
+	for i, d := range v.VMSpec.DataVolumes {
+		allErrs = append(allErrs, validateDataVolumeData(d, specPath.Child("DataVolumes").Index(i))...)
+	}
+	allErrs = append(allErrs, validateVolumeNames(v, specPath)...)
+
+	// Sizing comes from the instancetype when one is referenced
+	if v.VMSpec.Instancetype == nil {
+		allErrs = append(allErrs, validateSizing(v.VMSpec.VMISpec.VMDomainSpec, vmiPath.Child("VMDomainSpec"))...)
+	}
+
+	if v.VMSpec.VMISpec.NodeName != nil && v.VMSpec.VMISpec.AZ != nil {
+		allErrs = append(allErrs, field.Forbidden(vmiPath.Child("AZ"), "must not be set together with NodeName"))
+	}
+
+	allErrs = append(allErrs, ValidateDomain(v.VMSpec.VMISpec, vmiPath)...)
+	allErrs = append(allErrs, ValidateResources(v.VMSpec.VMISpec, vmiPath)...)
+	allErrs = append(allErrs, ValidateVolumes(v.VMSpec.VMISpec, vmiPath)...)
+	allErrs = append(allErrs, ValidateNetworks(v.VMSpec.VMISpec, vmiPath)...)
+	allErrs = append(allErrs, ValidateInstancetype(v.VMName, v.VMSpec, specPath)...)
+
+	return allErrs
+}
+
+func validateDataVolumeData(d dv.DataVolumeData, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if d.DVSource == nil && d.SourceRef == nil {
+		allErrs = append(allErrs, field.Required(fldPath.Child("DVSource"), "either DVSource or SourceRef is required"))
+	}
+	if d.DVSource != nil && d.SourceRef != nil {
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("SourceRef"), "must not be set together with DVSource"))
+	}
+	if d.SourceRef != nil {
+		if d.SourceRef.Kind != cdiv1beta1.DataVolumeDataSource {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("SourceRef", "Kind"), d.SourceRef.Kind, []string{cdiv1beta1.DataVolumeDataSource}))
+		}
+		if d.SourceRef.Name == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("SourceRef", "Name"), ""))
+		}
+	}
+	// spec.pvc is passed to Kubernetes as is, only spec.storage gets StorageProfile defaults
+	if !d.UseStorage && len(d.PVAccessModes) == 0 {
+		allErrs = append(allErrs, field.Required(fldPath.Child("PVAccessModes"), "required unless UseStorage is set"))
+	}
+	for i, mode := range d.PVAccessModes {
+		if !slices.Contains(supportedAccessModes, string(mode)) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("PVAccessModes").Index(i), mode, supportedAccessModes))
+		}
+	}
+	if d.PVMode != "" && !slices.Contains(supportedVolumeModes, string(d.PVMode)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("PVMode"), d.PVMode, supportedVolumeModes))
+	}
+	if d.StorageRequests == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("StorageRequests"), ""))
+	} else if _, err := utils.ParseResourceList("", "", d.StorageRequests, ""); err != nil {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("StorageRequests"), d.StorageRequests, err.Error()))
+	}
+
+	return allErrs
+}
+
+// Volume names become disk names, which KubeVirt requires to be unique DNS labels, and no two
+// disks may share a boot order. The generated names and the default cloud-init volume are checked too
+func validateVolumeNames(v VM, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	names := make(map[string]bool)
+	bootOrders := make(map[uint]bool)
+	callerVolumes := len(v.VMSpec.VMISpec.Volumes)
+
+	for i, vol := range collectVolumes(v.VMName, v.VMSpec) {
+		// Point at the field the volume originates from
+		var volPath *field.Path
+		switch {
+		case i < callerVolumes:
+			volPath = specPath.Child("VMISpec", "Volumes").Index(i)
+		case i < callerVolumes+len(v.VMSpec.DataVolumes):
+			volPath = specPath.Child("DataVolumes").Index(i - callerVolumes)
+		default:
+			volPath = specPath.Child("VMISpec", "Volumes")
+		}
+
+		for _, msg := range validation.IsDNS1123Label(vol.Name) {
+			allErrs = append(allErrs, field.Invalid(volPath.Child("Name"), vol.Name, msg))
+		}
+		if names[vol.Name] {
+			allErrs = append(allErrs, field.Duplicate(volPath.Child("Name"), vol.Name))
+		}
+		names[vol.Name] = true
+
+		// cloud-init disks are never booted from
+		if vol.Bootorder == nil || vol.Type == CloudInitNoCloud {
+			continue
+		}
+		if *vol.Bootorder == 0 {
+			allErrs = append(allErrs, field.Invalid(volPath.Child("Bootorder"), int(*vol.Bootorder), "must be at least 1"))
+		} else if bootOrders[*vol.Bootorder] {
+			allErrs = append(allErrs, field.Duplicate(volPath.Child("Bootorder"), int(*vol.Bootorder)))
+		}
+		bootOrders[*vol.Bootorder] = true
+	}
+
+	return allErrs
+}
+
+// A VM sized through its domain needs CPU and memory requests and a complete vCPU topology
+func validateSizing(domain VMDomainSpec, domainPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if domain.RequestsCPU == "" && domain.CPUOvercommitPercent == 0 {
+		allErrs = append(allErrs, field.Required(domainPath.Child("RequestsCPU"), "either RequestsCPU or CPUOvercommitPercent must be set"))
+	}
+	if domain.RequestsMemory == "" && domain.GuestMemory == "" {
+		allErrs = append(allErrs, field.Required(domainPath.Child("RequestsMemory"), "either RequestsMemory or GuestMemory must be set"))
+	}
+	topology := []struct {
+		name  string
+		value uint32
+	}{
+		{"Cores", domain.Cores},
+		{"Sockets", domain.Sockets},
+		{"Threads", domain.Threads},
+	}
+	for _, t := range topology {
+		if t.value == 0 {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child(t.name), int(t.value), "must be at least 1"))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateDomain rejects domain and disk combinations which KubeVirt or libvirt would refuse,
+// so a test fails on its input instead of on an API error or a VM that never starts.
+// fldPath is the path of the passed VMISpec.
+func ValidateDomain(vmispec VMISpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	domain := vmispec.VMDomainSpec
+	domainPath := fldPath.Child("VMDomainSpec")
+
+	if domain.Firmware != nil {
+		allErrs = append(allErrs, validateFirmware(domain, domainPath.Child("Firmware"))...)
+	}
+
+	if domain.DiskBus != "" && !slices.Contains(supportedDiskBuses, string(domain.DiskBus)) {
+		allErrs = append(allErrs, field.NotSupported(domainPath.Child("DiskBus"), domain.DiskBus, supportedDiskBuses))
+	}
+
+	if domain.HugepagesPageSize != "" && !slices.Contains(supportedPageSizes, domain.HugepagesPageSize) {
+		allErrs = append(allErrs, field.NotSupported(domainPath.Child("HugepagesPageSize"), domain.HugepagesPageSize, supportedPageSizes))
+	}
+
+	if domain.IsolateEmulatorThread && !domain.DedicatedCPUPlacement {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("IsolateEmulatorThread"), domain.IsolateEmulatorThread, "requires DedicatedCPUPlacement"))
+	}
+
+	if domain.NUMAGuestMappingPassthrough {
+		if !domain.DedicatedCPUPlacement {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child("NUMAGuestMappingPassthrough"), domain.NUMAGuestMappingPassthrough, "requires DedicatedCPUPlacement"))
+		}
+		if domain.HugepagesPageSize == "" {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child("NUMAGuestMappingPassthrough"), domain.NUMAGuestMappingPassthrough, "requires HugepagesPageSize"))
+		}
+	}
+
+	// Dedicated CPUs are pinned as whole host CPUs
+	if domain.DedicatedCPUPlacement {
+		if cpu, err := resource.ParseQuantity(domain.RequestsCPU); err == nil && cpu.MilliValue()%1000 != 0 {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child("RequestsCPU"), domain.RequestsCPU, "must be a whole number of CPUs with DedicatedCPUPlacement"))
+		}
+	}
+
+	for i, vol := range vmispec.Volumes {
+		allErrs = append(allErrs, validateDiskOptions(vol.Disk, domain.DiskBus, fldPath.Child("Volumes").Index(i).Child("Disk"))...)
+	}
+
+	return allErrs
+}
+
+// ValidateResources rejects quantities which do not parse, limits below requests and
+// resource settings which contradict the limit policy. fldPath is the path of the passed VMISpec.
+func ValidateResources(vmispec VMISpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	domain := vmispec.VMDomainSpec
+	domainPath := fldPath.Child("VMDomainSpec")
+
+	quantities := []struct {
+		name  string
+		value string
+	}{
+		{"RequestsCPU", domain.RequestsCPU},
+		{"RequestsMemory", domain.RequestsMemory},
+		{"LimitsCPU", domain.LimitsCPU},
+		{"LimitsMemory", domain.LimitsMemory},
+		{"GuestMemory", domain.GuestMemory},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		if _, err := resource.ParseQuantity(q.value); err != nil {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child(q.name), q.value, err.Error()))
+		}
+	}
+	// The rest of the checks compare quantities which must parse first
+	if len(allErrs) > 0 {
+		return allErrs
+	}
+
+	if domain.LimitPolicy != "" && !slices.Contains(supportedLimitPolicy, string(domain.LimitPolicy)) {
+		allErrs = append(allErrs, field.NotSupported(domainPath.Child("LimitPolicy"), domain.LimitPolicy, supportedLimitPolicy))
+	}
+	if domain.DedicatedCPUPlacement && domain.LimitPolicy != "" && domain.LimitPolicy != LimitPolicyGuaranteed {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("LimitPolicy"), domain.LimitPolicy, "DedicatedCPUPlacement requires LimitPolicyGuaranteed"))
+	}
+	if getLimitPolicy(domain) == LimitPolicyGuaranteed && ExpectedQOSClass(domain) != corev1.PodQOSGuaranteed {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("LimitPolicy"), LimitPolicyGuaranteed, "CPU and memory requests must be set, and equal any explicit limits"))
+	}
+	if domain.CPULimitsRatio < 0 {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("CPULimitsRatio"), domain.CPULimitsRatio, "must not be negative"))
+	}
+	if domain.CPULimitsRatio != 0 && getLimitPolicy(domain) != LimitPolicyRatio {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("CPULimitsRatio"), domain.CPULimitsRatio, "is only used by LimitPolicyRatio"))
+	}
+	if domain.MemoryOvercommitPercent < 0 {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("MemoryOvercommitPercent"), domain.MemoryOvercommitPercent, "must not be negative"))
+	}
+	if domain.MemoryOvercommitPercent > 0 && domain.GuestMemory == "" {
+		allErrs = append(allErrs, field.Required(domainPath.Child("GuestMemory"), "MemoryOvercommitPercent derives the memory requests from the guest memory"))
+	}
+	if domain.CPUOvercommitPercent < 0 {
+		allErrs = append(allErrs, field.Invalid(domainPath.Child("CPUOvercommitPercent"), domain.CPUOvercommitPercent, "must not be negative"))
+	}
+
+	resources := generateResources(domain)
+	limitFields := map[corev1.ResourceName]string{corev1.ResourceCPU: "LimitsCPU", corev1.ResourceMemory: "LimitsMemory"}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		limit, limited := resources.Limits[name]
+		request, requested := resources.Requests[name]
+		if limited && requested && limit.Cmp(request) < 0 {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child(limitFields[name]), limit.String(), fmt.Sprintf("must not be less than the %s requests, %s", name, request.String())))
+		}
+	}
+	// The guest can not be given more memory than the pod is allowed to use
+	if limit, ok := resources.Limits[corev1.ResourceMemory]; ok && domain.GuestMemory != "" {
+		if guest := resource.MustParse(domain.GuestMemory); guest.Cmp(limit) > 0 {
+			allErrs = append(allErrs, field.Invalid(domainPath.Child("GuestMemory"), domain.GuestMemory, fmt.Sprintf("must not exceed the memory limits, %s", limit.String())))
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateNetworks rejects network and interface combinations KubeVirt would refuse.
+// fldPath is the path of the passed VMISpec.
+func ValidateNetworks(vmispec VMISpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	var podNetworks int
+	names := make(map[string]bool)
+
+	for i, net := range vmispec.Networks {
+		netPath := fldPath.Child("Networks").Index(i)
+		// Networks without a type are generated as masquerade on the pod network
+		if net.Type == "" {
+			net.Type = MasqueradeNetwork
+		}
+		multus := net.Type == BridgeNetwork || net.Type == SRIOVNetwork
+
+		if net.Name == "" {
+			allErrs = append(allErrs, field.Required(netPath.Child("Name"), ""))
+		} else if names[net.Name] {
+			allErrs = append(allErrs, field.Duplicate(netPath.Child("Name"), net.Name))
+		}
+		names[net.Name] = true
+
+		if !slices.Contains(supportedNetworkTypes, string(net.Type)) {
+			allErrs = append(allErrs, field.NotSupported(netPath.Child("Type"), net.Type, supportedNetworkTypes))
+			continue
+		}
+		if !multus {
+			podNetworks++
+		}
+		if multus && (net.NADName == nil || *net.NADName == "") {
+			allErrs = append(allErrs, field.Required(netPath.Child("NADName"), fmt.Sprintf("%s networks are backed by a NetworkAttachmentDefinition", net.Type)))
+		}
+		if !multus && (net.NADName != nil || net.NADNamespace != "") {
+			allErrs = append(allErrs, field.Forbidden(netPath.Child("NADName"), fmt.Sprintf("%s networks use the pod network", net.Type)))
+		}
+		if net.BindingName != "" && net.Type != PasstNetwork && net.Type != SlirpNetwork {
+			allErrs = append(allErrs, field.Forbidden(netPath.Child("BindingName"), "only Passt and Slirp networks use a binding plugin"))
+		}
+
+		if net.Model != "" {
+			if net.Type == SRIOVNetwork {
+				allErrs = append(allErrs, field.Forbidden(netPath.Child("Model"), "SR-IOV VFs are passed through without an emulated model"))
+			} else if !slices.Contains(supportedInterfaceModels, string(net.Model)) {
+				allErrs = append(allErrs, field.NotSupported(netPath.Child("Model"), net.Model, supportedInterfaceModels))
+			}
+		}
+		if net.MacAddress != "" {
+			if _, err := stdnet.ParseMAC(net.MacAddress); err != nil {
+				allErrs = append(allErrs, field.Invalid(netPath.Child("MacAddress"), net.MacAddress, err.Error()))
+			}
+		}
+		if net.PciAddress != "" && !pciAddressRegex.MatchString(net.PciAddress) {
+			allErrs = append(allErrs, field.Invalid(netPath.Child("PciAddress"), net.PciAddress, "must be in the <domain>:<bus>:<slot>.<function> format"))
+		}
+	}
+
+	// The pod has a single primary interface
+	if podNetworks > 1 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("Networks"), podNetworks, "at most one Masquerade, Passt or Slirp network is allowed"))
+	}
+
+	return allErrs
+}
+
+// ValidateNAD makes sure the NetworkAttachmentDefinition carries the settings its type requires
+func ValidateNAD(n NAD, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if n.Name == "" {
+		allErrs = append(allErrs, field.Required(fldPath.Child("Name"), ""))
+	}
+	switch n.Type {
+	case BridgeNetwork:
+		if n.Bridge == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("Bridge"), "the node bridge is required by Bridge networks"))
+		}
+	case SRIOVNetwork:
+		if n.ResourceName == "" {
+			allErrs = append(allErrs, field.Required(fldPath.Child("ResourceName"), "the device plugin resource is required by SRIOV networks"))
+		}
+		if n.MacSpoofCheck {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("MacSpoofCheck"), "is set on the SR-IOV network policy rather than the NetworkAttachmentDefinition"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("Type"), n.Type, []string{string(BridgeNetwork), string(SRIOVNetwork)}))
+	}
+	if n.VLAN < 0 || n.VLAN > 4094 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("VLAN"), n.VLAN, "must be between 0 and 4094"))
+	}
+	if n.MTU < 0 {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("MTU"), n.MTU, "must not be negative"))
+	}
+
+	return allErrs
+}
+
+// ValidateVolumes makes sure each volume carries the source settings its type requires.
+// fldPath is the path of the passed VMISpec.
+func ValidateVolumes(vmispec VMISpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	for i, vol := range vmispec.Volumes {
+		volPath := fldPath.Child("Volumes").Index(i)
+		sourcePath := volPath.Child("Source")
+
+		if vol.Source.DataVolumeTemplate != nil {
+			if vol.Type != DataVolume {
+				allErrs = append(allErrs, field.Forbidden(sourcePath.Child("DataVolumeTemplate"), "only DataVolume volumes can be generated from a template"))
+			} else {
+				allErrs = append(allErrs, validateDataVolumeData(*vol.Source.DataVolumeTemplate, sourcePath.Child("DataVolumeTemplate"))...)
+			}
+		}
+
+		if vol.Source.Hotpluggable {
+			allErrs = append(allErrs, validateHotplugVolume(vol, vmispec.VMDomainSpec.DiskBus, volPath)...)
+		}
+
+		switch vol.Type {
+		case ContainerDisk:
+			if vol.Source.Image == "" {
+				allErrs = append(allErrs, field.Required(sourcePath.Child("Image"), "ContainerDisk volumes require an image"))
+			}
+		case EmptyDisk:
+			if _, err := resource.ParseQuantity(vol.Source.Capacity); err != nil {
+				allErrs = append(allErrs, field.Invalid(sourcePath.Child("Capacity"), vol.Source.Capacity, "EmptyDisk volumes require a valid capacity"))
+			}
+		case HostDisk:
+			if vol.Source.Path == "" {
+				allErrs = append(allErrs, field.Required(sourcePath.Child("Path"), "HostDisk volumes require a path"))
+			}
+			if vol.Source.Capacity != "" {
+				if _, err := resource.ParseQuantity(vol.Source.Capacity); err != nil {
+					allErrs = append(allErrs, field.Invalid(sourcePath.Child("Capacity"), vol.Source.Capacity, err.Error()))
+				}
+			}
+		case DownwardMetrics:
+			// KubeVirt exposes the metrics only through a virtio disk
+			if vol.Disk.Device != "" && vol.Disk.Device != DiskDeviceDisk {
+				allErrs = append(allErrs, field.Invalid(volPath.Child("Disk", "Device"), vol.Disk.Device, "DownwardMetrics volumes must be attached as a disk"))
+			}
+			if bus := diskBus(vol.Disk, vmispec.VMDomainSpec.DiskBus); bus != kubev1.DiskBusVirtio {
+				allErrs = append(allErrs, field.Invalid(volPath.Child("Disk", "Bus"), bus, "DownwardMetrics volumes require the virtio bus"))
+			}
+		default:
+			if !slices.Contains(supportedVolumeTypes, string(vol.Type)) {
+				allErrs = append(allErrs, field.NotSupported(volPath.Child("Type"), vol.Type, supportedVolumeTypes))
+			}
+		}
+	}
+
+	return allErrs
+}
+
+// ValidateInstancetype rejects references which KubeVirt would refuse, and domain sizing set on top
+// of an instancetype. vmname names the volumes generated for VMSpec.DataVolumes, which InferFromVolume
+// may refer to. fldPath is the path of the passed VMSpec.
+func ValidateInstancetype(vmname string, vmspec VMSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if it := vmspec.Instancetype; it != nil {
+		itPath := fldPath.Child("Instancetype")
+		allErrs = append(allErrs, validateSizingRef(it.Name, it.Kind, it.InferFromVolume, []string{InstancetypeKind, ClusterInstancetypeKind}, vmname, vmspec, itPath)...)
+
+		domain := vmspec.VMISpec.VMDomainSpec
+		domainPath := fldPath.Child("VMISpec", "VMDomainSpec")
+		sized := map[string]bool{
+			"RequestsCPU":                 domain.RequestsCPU != "",
+			"RequestsMemory":              domain.RequestsMemory != "",
+			"LimitsCPU":                   domain.LimitsCPU != "",
+			"LimitsMemory":                domain.LimitsMemory != "",
+			"GuestMemory":                 domain.GuestMemory != "",
+			"Cores":                       domain.Cores != 0,
+			"Sockets":                     domain.Sockets != 0,
+			"Threads":                     domain.Threads != 0,
+			"HugepagesPageSize":           domain.HugepagesPageSize != "",
+			"DedicatedCPUPlacement":       domain.DedicatedCPUPlacement,
+			"IsolateEmulatorThread":       domain.IsolateEmulatorThread,
+			"NUMAGuestMappingPassthrough": domain.NUMAGuestMappingPassthrough,
+		}
+		// Iterate in a fixed order to keep the error list stable
+		for _, name := range []string{"RequestsCPU", "RequestsMemory", "LimitsCPU", "LimitsMemory", "GuestMemory", "Cores", "Sockets", "Threads", "HugepagesPageSize",
+			"DedicatedCPUPlacement", "IsolateEmulatorThread", "NUMAGuestMappingPassthrough"} {
+			if sized[name] {
+				allErrs = append(allErrs, field.Forbidden(domainPath.Child(name), "must not be set together with an instancetype"))
+			}
+		}
+	}
+
+	if pref := vmspec.Preference; pref != nil {
+		allErrs = append(allErrs, validateSizingRef(pref.Name, pref.Kind, pref.InferFromVolume, []string{PreferenceKind, ClusterPreferenceKind}, vmname, vmspec, fldPath.Child("Preference"))...)
+	}
+
+	return allErrs
+}
+
+// Instancetype and preference references share the same rules
+func validateSizingRef(name, kind, inferFromVolume string, kinds []string, vmname string, vmspec VMSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	switch {
+	case name == "" && inferFromVolume == "":
+		allErrs = append(allErrs, field.Required(fldPath.Child("Name"), "either Name or InferFromVolume must be set"))
+	case name != "" && inferFromVolume != "":
+		allErrs = append(allErrs, field.Forbidden(fldPath.Child("InferFromVolume"), "must not be set together with Name"))
+	}
+
+	if kind != "" {
+		if inferFromVolume != "" {
+			allErrs = append(allErrs, field.Forbidden(fldPath.Child("Kind"), "the kind is inferred from the volume's labels"))
+		} else if !slices.Contains(kinds, kind) {
+			allErrs = append(allErrs, field.NotSupported(fldPath.Child("Kind"), kind, kinds))
+		}
+	}
+
+	if inferFromVolume != "" && !hasVolume(vmname, vmspec, inferFromVolume) {
+		allErrs = append(allErrs, field.NotFound(fldPath.Child("InferFromVolume"), inferFromVolume))
+	}
+
+	return allErrs
+}
+
+// Looks the name up among the volumes defined by the caller and the ones generated for
+// VMSpec.DataVolumes, which are named <vmname>-<index> as in collectVolumes
+func hasVolume(vmname string, vmspec VMSpec, name string) bool {
+	for _, vol := range vmspec.VMISpec.Volumes {
+		if vol.Name == name {
+			return true
+		}
+	}
+	for i := range vmspec.DataVolumes {
+		if fmt.Sprintf("%s-%d", vmname, i+1) == name {
+			return true
+		}
+	}
+	return false
+}
+
+func validateFirmware(domain VMDomainSpec, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	fw := domain.Firmware
+
+	switch fw.Type {
+	case EFIFirmware:
+		if fw.UseSerial {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("UseSerial"), fw.UseSerial, "only supported with BIOS firmware"))
+		}
+		if fw.SecureBoot && !domain.SMM {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("SecureBoot"), fw.SecureBoot, "requires SMM"))
+		}
+	case BIOSFirmware:
+		if fw.SecureBoot {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("SecureBoot"), fw.SecureBoot, "only supported with EFI firmware"))
+		}
+		if fw.Persistent {
+			allErrs = append(allErrs, field.Invalid(fldPath.Child("Persistent"), fw.Persistent, "only supported with EFI firmware"))
+		}
+	default:
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("Type"), fw.Type, []string{string(BIOSFirmware), string(EFIFirmware)}))
+	}
+
+	return allErrs
+}
+
+func validateDiskOptions(opts DiskOptions, defaultBus kubev1.DiskBus, fldPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if opts.Device != "" && !slices.Contains(supportedDiskDevices, string(opts.Device)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("Device"), opts.Device, supportedDiskDevices))
+	}
+
+	bus := diskBus(opts, defaultBus)
+	if !slices.Contains(supportedDiskBuses, string(bus)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("Bus"), bus, supportedDiskBuses))
+	} else if opts.Device == DiskDeviceCDRom && bus == kubev1.DiskBusVirtio {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("Bus"), bus, "CD-ROMs do not support the virtio bus"))
+	} else if opts.Device != DiskDeviceDisk && opts.Device != "" && bus == kubev1.DiskBusUSB {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("Bus"), bus, "only disks support the usb bus"))
+	}
+
+	if opts.Serial != "" && !diskSerialRegex.MatchString(opts.Serial) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("Serial"), opts.Serial, "must match "+diskSerialRegex.String()))
+	}
+
+	if opts.Cache != "" && !slices.Contains(supportedCacheModes, string(opts.Cache)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("Cache"), opts.Cache, supportedCacheModes))
+	}
+	if opts.IO != "" && !slices.Contains(supportedIOModes, string(opts.IO)) {
+		allErrs = append(allErrs, field.NotSupported(fldPath.Child("IO"), opts.IO, supportedIOModes))
+	}
+	// libvirt only allows native IO on uncached disks
+	if opts.IO == kubev1.IONative && (opts.Cache == kubev1.CacheWriteThrough || opts.Cache == kubev1.CacheWriteBack) {
+		allErrs = append(allErrs, field.Invalid(fldPath.Child("IO"), opts.IO, "native IO requires cache mode none"))
+	}
+
+	return allErrs
+}
+
+// KubeVirt only hotplugs DataVolumes and PVCs, as disks or LUNs on a bus which supports hotplug
+func validateHotplugVolume(vol Volume, defaultBus kubev1.DiskBus, volPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+
+	if vol.Type != DataVolume && vol.Type != PersistentVolumeClaim {
+		allErrs = append(allErrs, field.Forbidden(volPath.Child("Source", "Hotpluggable"), "only DataVolume and PersistentVolumeClaim volumes can be hotplugged"))
+	}
+	if vol.Disk.Device == DiskDeviceCDRom {
+		allErrs = append(allErrs, field.Invalid(volPath.Child("Disk", "Device"), vol.Disk.Device, "CD-ROMs can not be hotplugged"))
+	}
+	if bus := diskBus(vol.Disk, defaultBus); bus != kubev1.DiskBusSCSI && bus != kubev1.DiskBusVirtio {
+		allErrs = append(allErrs, field.Invalid(volPath.Child("Disk", "Bus"), bus, "hotplugged volumes require the scsi or virtio bus"))
+	}
+	if vol.Bootorder != nil {
+		allErrs = append(allErrs, field.Forbidden(volPath.Child("Bootorder"), "hotplugged volumes can not be booted from"))
+	}
+
+	return allErrs
+}
@@ -0,0 +1,530 @@
+package vm
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	dv "node-e2e/utils/datavolume"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	kubev1 "kubevirt.io/api/core/v1"
+	cdiv1beta1 "kubevirt.io/containerized-data-importer-api/pkg/apis/core/v1beta1"
+	"sigs.k8s.io/e2e-framework/pkg/env"
+	"sigs.k8s.io/e2e-framework/pkg/envconf"
+	"sigs.k8s.io/e2e-framework/pkg/features"
+)
+
+var testsEnvironment env.Environment
+
+func TestMain(m *testing.M) {
+	// create config from flags (always in TestMain or init handler of the package before calling envconf.NewFromFlags())
+	// This is needed in order to initilize flags provided by the e2e-framework module
+	cfg, err := envconf.NewFromFlags()
+	if err != nil {
+		fmt.Printf("failed to build envconf from flags: %s", err)
+		os.Exit(1)
+	}
+	testsEnvironment = env.NewWithConfig(cfg)
+
+	os.Exit(testsEnvironment.Run(m))
+}
+
+// A VM which passes validation, the cases below change one thing each
+func validVM() VM {
+	return VM{
+		VMName:    "test-vm",
+		Namespace: "default",
+		VMSpec: VMSpec{
+			Running: true,
+			DataVolumes: []dv.DataVolumeData{
+				{
+					DVSource: &cdiv1beta1.DataVolumeSource{
+						Registry: &cdiv1beta1.DataVolumeSourceRegistry{},
+					},
+					PVAccessModes:   []corev1.PersistentVolumeAccessMode{corev1.ReadWriteMany},
+					PVMode:          corev1.PersistentVolumeBlock,
+					StorageRequests: "10Gi",
+				},
+			},
+			VMISpec: VMISpec{
+				VMDomainSpec: VMDomainSpec{
+					RequestsCPU:    "1",
+					RequestsMemory: "2Gi",
+					Cores:          1,
+					Sockets:        1,
+					Threads:        1,
+				},
+			},
+		},
+	}
+}
+
+func TestVMValidate(t *testing.T) {
+	nodeName := "worker-0"
+	az := "az-a"
+	bootOrder := uint(1)
+
+	tests := []struct {
+		name   string
+		mutate func(v *VM)
+		// Paths of the expected errors, none for a valid VM
+		want []string
+	}{
+		{
+			name:   "valid VM",
+			mutate: func(v *VM) {},
+		},
+		{
+			name: "valid VM with explicit limits",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec.LimitsCPU = "2"
+				v.VMSpec.VMISpec.VMDomainSpec.LimitsMemory = "4Gi"
+			},
+		},
+		{
+			name: "CPU requests do not parse",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec.RequestsCPU = "abc"
+			},
+			want: []string{"VM.VMSpec.VMISpec.VMDomainSpec.RequestsCPU"},
+		},
+		{
+			name: "memory limits and guest memory do not parse",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec.LimitsMemory = "2 GiB"
+				v.VMSpec.VMISpec.VMDomainSpec.GuestMemory = "lots"
+			},
+			want: []string{"VM.VMSpec.VMISpec.VMDomainSpec.LimitsMemory", "VM.VMSpec.VMISpec.VMDomainSpec.GuestMemory"},
+		},
+		{
+			name: "CPU limits below requests",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec.LimitsCPU = "500m"
+			},
+			want: []string{"VM.VMSpec.VMISpec.VMDomainSpec.LimitsCPU"},
+		},
+		{
+			name: "storage requests do not parse",
+			mutate: func(v *VM) {
+				v.VMSpec.DataVolumes[0].StorageRequests = "ten"
+			},
+			want: []string{"VM.VMSpec.DataVolumes[0].StorageRequests"},
+		},
+		{
+			name: "NodeName and AZ set together",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.NodeName = &nodeName
+				v.VMSpec.VMISpec.AZ = &az
+			},
+			want: []string{"VM.VMSpec.VMISpec.AZ"},
+		},
+		{
+			name: "namespace missing",
+			mutate: func(v *VM) {
+				v.Namespace = ""
+			},
+			want: []string{"VM.Namespace"},
+		},
+		{
+			name: "network without a type is on the pod network",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.Networks = []Network{{Name: "nic-0"}}
+			},
+		},
+		{
+			name: "second network on the pod network",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.Networks = []Network{{Name: "nic-0"}, {Name: "nic-1", Type: MasqueradeNetwork}}
+			},
+			want: []string{"VM.VMSpec.VMISpec.Networks"},
+		},
+		{
+			name: "bridge network without a NetworkAttachmentDefinition",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.Networks = []Network{{Name: "nic-0", Type: BridgeNetwork}}
+			},
+			want: []string{"VM.VMSpec.VMISpec.Networks[0].NADName"},
+		},
+		{
+			name: "boot order shared with a DataVolume",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.Volumes = []Volume{
+					{Name: "scratch", Type: EmptyDisk, Bootorder: &bootOrder, Source: VolumeSourceOptions{Capacity: "1Gi"}},
+				}
+			},
+			want: []string{"VM.VMSpec.DataVolumes[0].Bootorder"},
+		},
+		{
+			name: "volume names which are not DNS labels or collide with a DataVolume",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.Volumes = []Volume{
+					{Name: "Scratch_1", Type: EmptyDisk, Source: VolumeSourceOptions{Capacity: "1Gi"}},
+					{Name: "test-vm-1", Type: EmptyDisk, Source: VolumeSourceOptions{Capacity: "1Gi"}},
+				}
+			},
+			want: []string{"VM.VMSpec.VMISpec.Volumes[0].Name", "VM.VMSpec.DataVolumes[0].Name"},
+		},
+		{
+			name: "instancetype inferred from the generated DataVolume",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec = VMDomainSpec{}
+				v.VMSpec.Instancetype = &InstancetypeRef{InferFromVolume: "test-vm-1"}
+			},
+		},
+		{
+			name: "instancetype inferred from a volume of the caller",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec = VMDomainSpec{}
+				v.VMSpec.VMISpec.Volumes = []Volume{{Name: "os", Type: ContainerDisk, Source: VolumeSourceOptions{Image: "quay.io/containerdisks/fedora:40"}}}
+				v.VMSpec.Instancetype = &InstancetypeRef{InferFromVolume: "os"}
+			},
+		},
+		{
+			name: "instancetype inferred from a mistyped volume",
+			mutate: func(v *VM) {
+				v.VMSpec.VMISpec.VMDomainSpec = VMDomainSpec{}
+				v.VMSpec.Instancetype = &InstancetypeRef{InferFromVolume: "test-vm-2"}
+			},
+			want: []string{"VM.VMSpec.Instancetype.InferFromVolume"},
+		},
+	}
+
+	feat := features.New("VM validation").
+		WithLabel("type", "VM").
+		Assess("Test validating valid and invalid VMs", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					v := validVM()
+					tt.mutate(&v)
+
+					errs := v.Validate()
+					if len(errs) != len(tt.want) {
+						t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs.ToAggregate())
+					}
+					for i, path := range tt.want {
+						if errs[i].Field != path {
+							t.Errorf("error %d is on %s, want %s: %v", i, errs[i].Field, path, errs[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestValidateDomain(t *testing.T) {
+	dedicated := VMDomainSpec{RequestsCPU: "2", DedicatedCPUPlacement: true}
+
+	tests := []struct {
+		name    string
+		vmispec VMISpec
+		// Paths of the expected errors, none for a valid domain
+		want []string
+	}{
+		{
+			name:    "defaults",
+			vmispec: VMISpec{},
+		},
+		{
+			name: "secure boot with EFI and SMM",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				Firmware: &Firmware{Type: EFIFirmware, SecureBoot: true, Persistent: true},
+				SMM:      true,
+			}},
+		},
+		{
+			name: "secure boot without SMM",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				Firmware: &Firmware{Type: EFIFirmware, SecureBoot: true},
+			}},
+			want: []string{"VMISpec.VMDomainSpec.Firmware.SecureBoot"},
+		},
+		{
+			name: "secure boot and persistent state without EFI",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				Firmware: &Firmware{Type: BIOSFirmware, SecureBoot: true, Persistent: true},
+				SMM:      true,
+			}},
+			want: []string{"VMISpec.VMDomainSpec.Firmware.SecureBoot", "VMISpec.VMDomainSpec.Firmware.Persistent"},
+		},
+		{
+			name: "serial console with EFI",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				Firmware: &Firmware{Type: EFIFirmware, UseSerial: true},
+			}},
+			want: []string{"VMISpec.VMDomainSpec.Firmware.UseSerial"},
+		},
+		{
+			name: "unsupported firmware",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				Firmware: &Firmware{Type: "UEFI"},
+			}},
+			want: []string{"VMISpec.VMDomainSpec.Firmware.Type"},
+		},
+		{
+			name: "unsupported disk bus and page size",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				DiskBus:           "ide",
+				HugepagesPageSize: "4Ki",
+			}},
+			want: []string{"VMISpec.VMDomainSpec.DiskBus", "VMISpec.VMDomainSpec.HugepagesPageSize"},
+		},
+		{
+			name: "dedicated CPUs with an isolated emulator thread and NUMA passthrough",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				RequestsCPU:                 "4",
+				DedicatedCPUPlacement:       true,
+				IsolateEmulatorThread:       true,
+				NUMAGuestMappingPassthrough: true,
+				HugepagesPageSize:           "1Gi",
+			}},
+		},
+		{
+			name: "isolated emulator thread without dedicated CPUs",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				IsolateEmulatorThread: true,
+			}},
+			want: []string{"VMISpec.VMDomainSpec.IsolateEmulatorThread"},
+		},
+		{
+			name: "NUMA passthrough without hugepages",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				RequestsCPU:                 "4",
+				DedicatedCPUPlacement:       true,
+				NUMAGuestMappingPassthrough: true,
+			}},
+			want: []string{"VMISpec.VMDomainSpec.NUMAGuestMappingPassthrough"},
+		},
+		{
+			name: "NUMA passthrough without dedicated CPUs or hugepages",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				NUMAGuestMappingPassthrough: true,
+			}},
+			want: []string{"VMISpec.VMDomainSpec.NUMAGuestMappingPassthrough", "VMISpec.VMDomainSpec.NUMAGuestMappingPassthrough"},
+		},
+		{
+			name: "fractional CPU requests with dedicated CPUs",
+			vmispec: VMISpec{VMDomainSpec: VMDomainSpec{
+				RequestsCPU:           "1500m",
+				DedicatedCPUPlacement: true,
+			}},
+			want: []string{"VMISpec.VMDomainSpec.RequestsCPU"},
+		},
+		{
+			name: "disk options",
+			vmispec: VMISpec{
+				VMDomainSpec: dedicated,
+				Volumes: []Volume{
+					{Name: "os", Disk: DiskOptions{Bus: kubev1.DiskBusSATA, Serial: "os-disk_01", Cache: kubev1.CacheNone, IO: kubev1.IONative}},
+					{Name: "iso", Disk: DiskOptions{Device: DiskDeviceCDRom, Bus: kubev1.DiskBusSATA}},
+				},
+			},
+		},
+		{
+			name: "unsupported disk bus of a volume",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "os", Disk: DiskOptions{Bus: "ide"}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Disk.Bus"},
+		},
+		{
+			name: "CD-ROM on the virtio bus",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "iso", Disk: DiskOptions{Device: DiskDeviceCDRom, Bus: kubev1.DiskBusVirtio}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Disk.Bus"},
+		},
+		{
+			name: "LUN on the usb bus",
+			vmispec: VMISpec{
+				VMDomainSpec: VMDomainSpec{DiskBus: kubev1.DiskBusUSB},
+				Volumes: []Volume{
+					{Name: "lun", Disk: DiskOptions{Device: DiskDeviceLUN}},
+				},
+			},
+			want: []string{"VMISpec.Volumes[0].Disk.Bus"},
+		},
+		{
+			name: "serial, cache and IO modes",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "os", Disk: DiskOptions{Serial: "os disk", Cache: "unsafe", IO: "io_uring"}},
+				{Name: "data", Disk: DiskOptions{Cache: kubev1.CacheWriteBack, IO: kubev1.IONative}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Disk.Serial", "VMISpec.Volumes[0].Disk.Cache", "VMISpec.Volumes[0].Disk.IO", "VMISpec.Volumes[1].Disk.IO"},
+		},
+	}
+
+	feat := features.New("VM domain validation").
+		WithLabel("type", "VM").
+		Assess("Test validating firmware, CPU placement, hugepages and disk options", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					errs := ValidateDomain(tt.vmispec, field.NewPath("VMISpec"))
+					if len(errs) != len(tt.want) {
+						t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs.ToAggregate())
+					}
+					for i, path := range tt.want {
+						if errs[i].Field != path {
+							t.Errorf("error %d is on %s, want %s: %v", i, errs[i].Field, path, errs[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestValidateVolumes(t *testing.T) {
+	tests := []struct {
+		name    string
+		vmispec VMISpec
+		// Paths of the expected errors, none for valid volumes
+		want []string
+	}{
+		{
+			name: "volumes of every source",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "os", Type: ContainerDisk, Source: VolumeSourceOptions{Image: "quay.io/containerdisks/fedora:40"}},
+				{Name: "scratch", Type: EmptyDisk, Source: VolumeSourceOptions{Capacity: "1Gi"}},
+				{Name: "host", Type: HostDisk, Source: VolumeSourceOptions{Path: "/var/lib/node-e2e/disk.img", Capacity: "1Gi"}},
+				{Name: "metrics", Type: DownwardMetrics},
+			}},
+		},
+		{
+			name: "unsupported volume type",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "data", Type: "Floppy"},
+			}},
+			want: []string{"VMISpec.Volumes[0].Type"},
+		},
+		{
+			name: "ContainerDisk without an image",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "os", Type: ContainerDisk},
+			}},
+			want: []string{"VMISpec.Volumes[0].Source.Image"},
+		},
+		{
+			name: "EmptyDisk capacity does not parse",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "scratch", Type: EmptyDisk, Source: VolumeSourceOptions{Capacity: "1 GiB"}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Source.Capacity"},
+		},
+		{
+			name: "HostDisk without a path and with a capacity which does not parse",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "host", Type: HostDisk, Source: VolumeSourceOptions{Capacity: "big"}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Source.Path", "VMISpec.Volumes[0].Source.Capacity"},
+		},
+		{
+			name: "DownwardMetrics as a CD-ROM",
+			vmispec: VMISpec{Volumes: []Volume{
+				{Name: "metrics", Type: DownwardMetrics, Disk: DiskOptions{Device: DiskDeviceCDRom}},
+			}},
+			want: []string{"VMISpec.Volumes[0].Disk.Device", "VMISpec.Volumes[0].Disk.Bus"},
+		},
+		{
+			name: "DownwardMetrics on the default bus of the domain",
+			vmispec: VMISpec{
+				VMDomainSpec: VMDomainSpec{DiskBus: kubev1.DiskBusSCSI},
+				Volumes: []Volume{
+					{Name: "metrics", Type: DownwardMetrics},
+				},
+			},
+			want: []string{"VMISpec.Volumes[0].Disk.Bus"},
+		},
+	}
+
+	feat := features.New("VM volumes validation").
+		WithLabel("type", "VM").
+		Assess("Test validating the source settings of each volume type", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					errs := ValidateVolumes(tt.vmispec, field.NewPath("VMISpec"))
+					if len(errs) != len(tt.want) {
+						t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs.ToAggregate())
+					}
+					for i, path := range tt.want {
+						if errs[i].Field != path {
+							t.Errorf("error %d is on %s, want %s: %v", i, errs[i].Field, path, errs[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
+
+func TestValidateResources(t *testing.T) {
+	tests := []struct {
+		name   string
+		domain VMDomainSpec
+		want   []field.ErrorType
+	}{
+		{
+			name:   "requests only",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi"},
+		},
+		{
+			name:   "guaranteed",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi", LimitPolicy: LimitPolicyGuaranteed},
+		},
+		{
+			name:   "quantities which do not parse are all reported",
+			domain: VMDomainSpec{RequestsCPU: "two", RequestsMemory: "4Gb!", LimitsCPU: "x"},
+			want:   []field.ErrorType{field.ErrorTypeInvalid, field.ErrorTypeInvalid, field.ErrorTypeInvalid},
+		},
+		{
+			name:   "unknown limit policy",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi", LimitPolicy: "Burstable"},
+			want:   []field.ErrorType{field.ErrorTypeNotSupported},
+		},
+		{
+			name:   "guaranteed with limits above requests",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi", LimitsMemory: "8Gi", LimitPolicy: LimitPolicyGuaranteed},
+			want:   []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:   "guest memory above the memory limits",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi", LimitsMemory: "4Gi", GuestMemory: "6Gi"},
+			want:   []field.ErrorType{field.ErrorTypeInvalid},
+		},
+		{
+			name:   "negative overcommit",
+			domain: VMDomainSpec{RequestsCPU: "2", RequestsMemory: "4Gi", MemoryOvercommitPercent: -1},
+			want:   []field.ErrorType{field.ErrorTypeInvalid},
+		},
+	}
+
+	feat := features.New("VM resources validation").
+		WithLabel("type", "VM").
+		Assess("Test validating VM requests, limits and limit policies", func(ctx context.Context, t *testing.T, c *envconf.Config) context.Context {
+			for _, tt := range tests {
+				t.Run(tt.name, func(t *testing.T) {
+					errs := ValidateResources(VMISpec{VMDomainSpec: tt.domain}, field.NewPath("VMISpec"))
+					if len(errs) != len(tt.want) {
+						t.Fatalf("got %d errors, want %d: %v", len(errs), len(tt.want), errs.ToAggregate())
+					}
+					for i, errType := range tt.want {
+						if errs[i].Type != errType {
+							t.Errorf("error %d is %s, want %s: %v", i, errs[i].Type, errType, errs[i])
+						}
+					}
+				})
+			}
+			return ctx
+		}).Feature()
+
+	testsEnvironment.Test(t, feat)
+}
@@ -7,12 +7,26 @@ import (
 	"node-e2e/utils"
 	dv "node-e2e/utils/datavolume"
 
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	kubev1 "kubevirt.io/api/core/v1"
 	"sigs.k8s.io/e2e-framework/pkg/envconf"
 )
 
-func GenerateVirtualMachine(v VM) *kubev1.VirtualMachine {
+const (
+	defaultMachineType string = "pc-q35-rhel8.6.0"
+	// Name of the cloud-init NoCloud volume added when the caller did not define one
+	defaultCloudInitVolumeName string = "cloudinit"
+)
+
+// GenerateVirtualMachine validates the VM description first and returns all of its errors aggregated,
+// rather than an object the API would reject or, worse, accept with different semantics
+func GenerateVirtualMachine(v VM) (*kubev1.VirtualMachine, error) {
+	if errs := v.Validate(); len(errs) > 0 {
+		return nil, fmt.Errorf("invalid VM %s: %v", v.VMName, errs.ToAggregate())
+	}
+
 	vm := kubev1.VirtualMachine{
 		TypeMeta: metav1.TypeMeta{
 			Kind:       "VirtualMachine",
@@ -24,35 +38,57 @@ func GenerateVirtualMachine(v VM) *kubev1.VirtualMachine {
 			Labels:      v.Labels,
 			Annotations: v.Annotations,
 		},
-		Spec: *generateVirtualMachineSpec(v.VMName, v.Namespace, v.VMSpec),
 	}
-	return &vm
+	spec, err := generateVirtualMachineSpec(v.VMName, v.Namespace, v.VMSpec)
+	if err != nil {
+		return nil, fmt.Errorf("invalid VM %s: %v", v.VMName, err)
+	}
+	vm.Spec = *spec
+	return &vm, nil
 }
 
-func generateVirtualMachineSpec(vmname, ns string, vmspec VMSpec) *kubev1.VirtualMachineSpec {
+func generateVirtualMachineSpec(vmname, ns string, vmspec VMSpec) (*kubev1.VirtualMachineSpec, error) {
 	var dvTemplates []kubev1.DataVolumeTemplateSpec
-	var counter uint = 1
 
-	for _, d := range vmspec.DataVolumes {
-		// Generate unique name for each DV
-		var volName string = fmt.Sprintf("%s-%d", vmname, counter)
-		// Generate DVs
-		dvTemplates = append(dvTemplates, *dv.GenerateDataVolumeTemplateSpec(volName, d))
-		bootOrder := counter
-		// Create Volume structs for later kubev1.Volume and kubev1.Disk generation
-		vmspec.VMISpec.Volumes = append(vmspec.VMISpec.Volumes, Volume{Name: volName, Type: DataVolume, Bootorder: &bootOrder})
-		counter++
+	volumes := collectVolumes(vmname, vmspec)
+	for _, vol := range volumes {
+		// Generate DVs for the volumes which carry a template
+		if vol.Type == DataVolume && vol.Source.DataVolumeTemplate != nil {
+			dvTemplates = append(dvTemplates, *dv.GenerateDataVolumeTemplateSpec(volumeSourceName(vol), *vol.Source.DataVolumeTemplate))
+		}
 	}
 
+	// Work on a copy, the passed VMSpec is left as is
+	vmispec := vmspec.VMISpec
+	vmispec.Volumes = volumes
+	vmispec.VMDomainSpec.withPreference = vmspec.Preference != nil
+
+	template, err := generateVirtualMachineInstanceTemplateSpec(vmname, ns, vmispec)
+	if err != nil {
+		return nil, err
+	}
 	vms := kubev1.VirtualMachineSpec{
 		Running:             &vmspec.Running,
 		DataVolumeTemplates: dvTemplates,
-		Template:            generateVirtualMachineInstanceTemplateSpec(vmname, ns, vmspec.VMISpec),
+		Template:            template,
+		Instancetype:        generateInstancetypeMatcher(vmspec.Instancetype),
+		Preference:          generatePreferenceMatcher(vmspec.Preference),
+	}
+
+	// KubeVirt rejects VMs which size the domain on top of an instancetype
+	if vmspec.Instancetype != nil {
+		vms.Template.Spec.Domain.CPU = nil
+		vms.Template.Spec.Domain.Memory = nil
+		vms.Template.Spec.Domain.Resources = kubev1.ResourceRequirements{}
 	}
-	return &vms
+	return &vms, nil
 }
 
-func generateVirtualMachineInstanceTemplateSpec(vmname, ns string, vmispec VMISpec) *kubev1.VirtualMachineInstanceTemplateSpec {
+func generateVirtualMachineInstanceTemplateSpec(vmname, ns string, vmispec VMISpec) (*kubev1.VirtualMachineInstanceTemplateSpec, error) {
+	spec, err := generateVirtualmachineInstanceSpec(vmname, ns, vmispec)
+	if err != nil {
+		return nil, err
+	}
 	vmits := kubev1.VirtualMachineInstanceTemplateSpec{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        vmname,
@@ -60,35 +96,49 @@ func generateVirtualMachineInstanceTemplateSpec(vmname, ns string, vmispec VMISp
 			Labels:      vmispec.Labels,
 			Annotations: vmispec.Annotations,
 		},
-		Spec: *generateVirtualmachineInstanceSpec(vmname, ns, vmispec),
+		Spec: *spec,
 	}
-	return &vmits
+	return &vmits, nil
 }
 
-func generateVirtualmachineInstanceSpec(vmname, ns string, vmispec VMISpec) *kubev1.VirtualMachineInstanceSpec {
+// Expects vmispec.Volumes to be complete, see collectVolumes
+func generateVirtualmachineInstanceSpec(vmname, ns string, vmispec VMISpec) (*kubev1.VirtualMachineInstanceSpec, error) {
 	var networks []kubev1.Network
+	var interfaces []kubev1.Interface
 	var volumes []kubev1.Volume
+	var disks []kubev1.Disk
 	var nodeSelector map[string]string
 
 	for _, net := range vmispec.Networks {
 		// Generate kubev1.Network list
-		networks = append(networks, generateNetwork(ns, net))
+		network, err := generateNetwork(ns, net)
+		if err != nil {
+			return nil, err
+		}
+		networks = append(networks, network)
 		// Generate kubev1.Interrace list based on the network list as they are based on the defined network
-		vmispec.VMDomainSpec.interfaces = append(vmispec.VMDomainSpec.interfaces, generateInterface(net.Name, net.Type))
+		iface := generateInterface(net)
+		// Let the preference pick the interface model unless one was set
+		if vmispec.VMDomainSpec.withPreference && net.Model == "" {
+			iface.Model = ""
+		}
+		interfaces = append(interfaces, iface)
 	}
 
-	var defaultCloudInitNoCloud Volume = Volume{Name: "cloudinit", Type: CloudInitNoCloud}
-	vmispec.Volumes = append(vmispec.Volumes, defaultCloudInitNoCloud)
-
 	for _, vol := range vmispec.Volumes {
-		if vol.Type == DataVolume {
-			volumes = append(volumes, generateVolume(vol.Name))
-			vmispec.VMDomainSpec.disks = append(vmispec.VMDomainSpec.disks, generateDisk(vol.Name, vol.Bootorder))
-		} else if vol.Type == CloudInitNoCloud {
-			volumes = append(volumes, generateCloudInitNoCloudVolume(vol.Name))
-			vmispec.VMDomainSpec.disks = append(vmispec.VMDomainSpec.disks, generateDisk(vol.Name, nil))
+		volume, err := generateVolumeFromSource(vol)
+		if err != nil {
+			return nil, err
+		}
+		// cloud-init disks are never booted from
+		if vol.Type == CloudInitNoCloud {
+			vol.Bootorder = nil
 		}
+		volumes = append(volumes, volume)
+		disks = append(disks, generateDisk(vol, vmispec.VMDomainSpec))
 	}
+	vmispec.VMDomainSpec.interfaces = interfaces
+	vmispec.VMDomainSpec.disks = disks
 
 	nodeSelector = make(map[string]string)
 	if vmispec.AZ != nil {
@@ -115,68 +165,386 @@ func generateVirtualmachineInstanceSpec(vmname, ns string, vmispec VMISpec) *kub
 	if len(nodeSelector) > 0 {
 		spec.NodeSelector = nodeSelector
 	}
-	return &spec
+	return &spec, nil
 }
 
 func generateDomainSpec(domain VMDomainSpec) *kubev1.DomainSpec {
 	domainSpec := kubev1.DomainSpec{
 		CPU: &kubev1.CPU{
-			Cores:   domain.Cores,
-			Sockets: domain.Sockets,
-			Threads: domain.Threads,
+			Cores:                 domain.Cores,
+			Sockets:               domain.Sockets,
+			Threads:               domain.Threads,
+			DedicatedCPUPlacement: domain.DedicatedCPUPlacement,
+			IsolateEmulatorThread: domain.IsolateEmulatorThread,
 		},
 		Devices: kubev1.Devices{
-			Disks:                      domain.disks,
-			Interfaces:                 domain.interfaces,
-			NetworkInterfaceMultiQueue: func(b bool) *bool { return &b }(true),
-			Rng:                        &kubev1.Rng{},
-		},
-		Machine: &kubev1.Machine{
-			Type: "pc-q35-rhel8.6.0",
-		},
-		Resources: kubev1.ResourceRequirements{
-			Limits:   *utils.GenerateResourceList(utils.GetCPULimitsFromRequests(domain.RequestsCPU), "", "", ""),
-			Requests: *utils.GenerateResourceList(domain.RequestsCPU, domain.RequestsMemory, "", ""),
+			Disks:      domain.disks,
+			Interfaces: domain.interfaces,
 		},
+		Resources: generateResources(domain),
+		Firmware:  generateFirmware(domain.Firmware),
+	}
+
+	// Defaults are only applied when there is no preference to provide them
+	if domain.MachineType != "" {
+		domainSpec.Machine = &kubev1.Machine{Type: domain.MachineType}
+	} else if !domain.withPreference {
+		domainSpec.Machine = &kubev1.Machine{Type: defaultMachineType}
+	}
+	if domain.NetworkInterfaceMultiQueue != nil || !domain.withPreference {
+		domainSpec.Devices.NetworkInterfaceMultiQueue = func(b bool) *bool { return &b }(boolOrDefault(domain.NetworkInterfaceMultiQueue, true))
+	}
+	if boolOrDefault(domain.Rng, !domain.withPreference) {
+		domainSpec.Devices.Rng = &kubev1.Rng{}
+	}
+	if domain.SMM {
+		domainSpec.Features = &kubev1.Features{
+			SMM: &kubev1.FeatureState{
+				Enabled: func(b bool) *bool { return &b }(true),
+			},
+		}
+	}
+	if domain.GuestMemory != "" || domain.HugepagesPageSize != "" {
+		domainSpec.Memory = &kubev1.Memory{}
+	}
+	if guest, err := resource.ParseQuantity(domain.GuestMemory); err == nil {
+		domainSpec.Memory.Guest = &guest
+	}
+	if domain.HugepagesPageSize != "" {
+		domainSpec.Memory.Hugepages = &kubev1.Hugepages{
+			PageSize: domain.HugepagesPageSize,
+		}
+	}
+	if domain.NUMAGuestMappingPassthrough {
+		domainSpec.CPU.NUMA = &kubev1.NUMA{
+			GuestMappingPassthrough: &kubev1.NUMAGuestMappingPassthrough{},
+		}
 	}
 
 	return &domainSpec
 }
 
-// Basic Disk creation with disk device using vitio bus.
-// There are many more options but for the purpose of testing this might be enough.
-func generateDisk(devName string, bootorder *uint) kubev1.Disk {
-	return kubev1.Disk{
-		Name: devName,
-		DiskDevice: kubev1.DiskDevice{
+// Requests are taken as given or derived from the overcommit percentages,
+// limits are taken as given or derived from the limit policy
+func generateResources(domain VMDomainSpec) kubev1.ResourceRequirements {
+	requests := *utils.GenerateResourceList(domain.RequestsCPU, domain.RequestsMemory, "", "")
+	if _, ok := requests[corev1.ResourceMemory]; !ok && domain.MemoryOvercommitPercent > 0 {
+		if guest, err := resource.ParseQuantity(domain.GuestMemory); err == nil {
+			requests[corev1.ResourceMemory] = utils.ScaleQuantityByOvercommit(guest, domain.MemoryOvercommitPercent)
+		}
+	}
+	if _, ok := requests[corev1.ResourceCPU]; !ok && domain.CPUOvercommitPercent > 0 {
+		vcpus := resource.NewQuantity(int64(vCPUs(domain)), resource.DecimalSI)
+		requests[corev1.ResourceCPU] = utils.ScaleQuantityByOvercommit(*vcpus, domain.CPUOvercommitPercent)
+	}
+
+	limits := make(corev1.ResourceList)
+	switch getLimitPolicy(domain) {
+	case LimitPolicyGuaranteed:
+		for name, quantity := range requests {
+			limits[name] = quantity
+		}
+		// KubeVirt requests the guest memory when no memory requests are set, so limit it the same
+		if _, ok := limits[corev1.ResourceMemory]; !ok {
+			if guest, err := resource.ParseQuantity(domain.GuestMemory); err == nil {
+				limits[corev1.ResourceMemory] = guest
+			}
+		}
+	case LimitPolicyRatio:
+		if cpu, ok := requests[corev1.ResourceCPU]; ok {
+			ratio := domain.CPULimitsRatio
+			if ratio == 0 {
+				ratio = utils.RequestsToLimitsRatio
+			}
+			limits[corev1.ResourceCPU] = resource.MustParse(utils.GetCPULimitsFromRequestsWithRatio(cpu.String(), ratio))
+		}
+	}
+	for name, quantity := range *utils.GenerateResourceList(domain.LimitsCPU, domain.LimitsMemory, "", "") {
+		limits[name] = quantity
+	}
+
+	return kubev1.ResourceRequirements{
+		Requests:                requests,
+		Limits:                  limits,
+		OvercommitGuestOverhead: domain.OvercommitGuestOverhead,
+	}
+}
+
+// Dedicated CPUs are only granted to Guaranteed QoS pods, which requires limits to equal requests
+func getLimitPolicy(domain VMDomainSpec) LimitPolicy {
+	if domain.DedicatedCPUPlacement {
+		return LimitPolicyGuaranteed
+	}
+	if domain.LimitPolicy == "" {
+		return LimitPolicyRatio
+	}
+	return domain.LimitPolicy
+}
+
+// Unset topology fields count as 1, as KubeVirt defaults them
+func vCPUs(domain VMDomainSpec) uint32 {
+	vcpus := uint32(1)
+	for _, n := range []uint32{domain.Cores, domain.Sockets, domain.Threads} {
+		if n > 0 {
+			vcpus *= n
+		}
+	}
+	return vcpus
+}
+
+// ExpectedQOSClass returns the QoS class the virt-launcher pod of the given domain is expected to get.
+// Memory is always requested by KubeVirt, so a VM is never BestEffort
+func ExpectedQOSClass(domain VMDomainSpec) corev1.PodQOSClass {
+	resources := generateResources(domain)
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		limit, ok := resources.Limits[name]
+		if !ok {
+			return corev1.PodQOSBurstable
+		}
+		// Requests default to limits when not set
+		if request, ok := resources.Requests[name]; ok && !request.Equal(limit) {
+			return corev1.PodQOSBurstable
+		}
+	}
+	return corev1.PodQOSGuaranteed
+}
+
+// Returns nil if no firmware was requested, leaving KubeVirt to default to BIOS
+func generateFirmware(fw *Firmware) *kubev1.Firmware {
+	if fw == nil {
+		return nil
+	}
+
+	var bootloader kubev1.Bootloader
+	if fw.Type == EFIFirmware {
+		bootloader.EFI = &kubev1.EFI{
+			SecureBoot: func(b bool) *bool { return &b }(fw.SecureBoot),
+			Persistent: func(b bool) *bool { return &b }(fw.Persistent),
+		}
+	} else {
+		bootloader.BIOS = &kubev1.BIOS{
+			UseSerial: func(b bool) *bool { return &b }(fw.UseSerial),
+		}
+	}
+	return &kubev1.Firmware{
+		Bootloader: &bootloader,
+	}
+}
+
+// Creates a disk, CD-ROM or LUN device for the volume. A bus not set on the volume
+// is taken from the domain's default bus, or from the preference if there is one.
+func generateDisk(vol Volume, domain VMDomainSpec) kubev1.Disk {
+	disk := kubev1.Disk{
+		Name:      vol.Name,
+		BootOrder: vol.Bootorder, // Can be nil as it is a pointer
+		Serial:    vol.Disk.Serial,
+		Cache:     vol.Disk.Cache,
+		IO:        vol.Disk.IO,
+	}
+
+	bus := diskBus(vol.Disk, domain.DiskBus)
+	if domain.withPreference && vol.Disk.Bus == "" && domain.DiskBus == "" {
+		bus = ""
+	}
+	switch vol.Disk.Device {
+	case DiskDeviceCDRom:
+		disk.DiskDevice = kubev1.DiskDevice{
+			CDRom: &kubev1.CDRomTarget{
+				Bus: bus,
+				// CD-ROMs are always read only
+				ReadOnly: func(b bool) *bool { return &b }(true),
+			},
+		}
+	case DiskDeviceLUN:
+		disk.DiskDevice = kubev1.DiskDevice{
+			LUN: &kubev1.LunTarget{
+				Bus:      bus,
+				ReadOnly: vol.Disk.ReadOnly,
+			},
+		}
+	default:
+		disk.DiskDevice = kubev1.DiskDevice{
 			Disk: &kubev1.DiskTarget{
-				Bus: "virtio",
+				Bus:      bus,
+				ReadOnly: vol.Disk.ReadOnly,
 			},
-		},
-		BootOrder: bootorder, // Can be nil as it is a pointer
+		}
+	}
+	return disk
+}
+
+// CD-ROMs can not use virtio, therefore they do not inherit the domain's default bus
+func diskBus(opts DiskOptions, defaultBus kubev1.DiskBus) kubev1.DiskBus {
+	if opts.Bus != "" {
+		return opts.Bus
+	}
+	if opts.Device == DiskDeviceCDRom {
+		return kubev1.DiskBusSATA
+	}
+	if defaultBus != "" {
+		return defaultBus
 	}
+	return kubev1.DiskBusVirtio
 }
 
-// interfaceBindingMethod being "bridge" or otherwise default to Masquerade interface
-func generateInterface(ifName string, interfaceBindingMethod NetworkType) kubev1.Interface {
+func generateInterface(net Network) kubev1.Interface {
 	var iface kubev1.Interface
-	if interfaceBindingMethod == BridgeNetwork {
+	switch net.Type {
+	case BridgeNetwork:
 		iface = *kubev1.DefaultBridgeNetworkInterface()
-	} else {
-		// Default to masquerade
+	case SRIOVNetwork:
+		iface.SRIOV = &kubev1.InterfaceSRIOV{}
+	case PasstNetwork, SlirpNetwork:
+		iface.Binding = &kubev1.PluginBinding{
+			Name: bindingName(net),
+		}
+	default:
+		// Masquerade, also when the type is not set
 		iface = *kubev1.DefaultMasqueradeNetworkInterface()
 	}
-	iface.Name = ifName
-	iface.Model = "virtio"
+	iface.Name = net.Name
+	iface.MacAddress = net.MacAddress
+	iface.PciAddress = net.PciAddress
+	// A VF is passed through as is, it has no emulated model
+	if net.Type != SRIOVNetwork {
+		iface.Model = string(net.Model)
+		if iface.Model == "" {
+			iface.Model = string(InterfaceModelVirtio)
+		}
+	}
 	return iface
 }
 
-func generateVolume(name string) kubev1.Volume {
+func bindingName(net Network) string {
+	if net.BindingName != "" {
+		return net.BindingName
+	}
+	return strings.ToLower(string(net.Type))
+}
+
+// Returns the caller's volumes followed by one per VMSpec.DataVolumes entry and the default cloud-init
+// volume, in a new slice so the passed VMSpec is never modified
+func collectVolumes(vmname string, vmspec VMSpec) []Volume {
+	volumes := make([]Volume, 0, len(vmspec.VMISpec.Volumes)+len(vmspec.DataVolumes)+1)
+	volumes = append(volumes, vmspec.VMISpec.Volumes...)
+
+	for i, d := range vmspec.DataVolumes {
+		// DataVolumes are named after the VM and booted in the order they were given
+		bootOrder := uint(i + 1)
+		template := d
+		volumes = append(volumes, Volume{
+			Name:      fmt.Sprintf("%s-%d", vmname, i+1),
+			Type:      DataVolume,
+			Bootorder: &bootOrder,
+			Source: VolumeSourceOptions{
+				DataVolumeTemplate: &template,
+			},
+		})
+	}
+
+	// A cloud-init volume defined by the caller replaces the default one
+	for _, vol := range vmspec.VMISpec.Volumes {
+		if vol.Type == CloudInitNoCloud {
+			return volumes
+		}
+	}
+	return append(volumes, Volume{
+		Name: defaultCloudInitVolumeName,
+		Type: CloudInitNoCloud,
+		Source: VolumeSourceOptions{
+			Password:         vmspec.VMISpec.CloudInitPassword,
+			SSHAuthorizedKey: vmspec.VMISpec.SSHAuthorizedKey,
+		},
+	})
+}
+
+// The referenced object defaults to the volume name
+func volumeSourceName(vol Volume) string {
+	if vol.Source.Name != "" {
+		return vol.Source.Name
+	}
+	return vol.Name
+}
+
+func generateVolumeFromSource(vol Volume) (kubev1.Volume, error) {
+	var source kubev1.VolumeSource
+	sourceName := volumeSourceName(vol)
+
+	switch vol.Type {
+	case DataVolume:
+		volume := generateVolume(vol.Name, sourceName)
+		volume.DataVolume.Hotpluggable = vol.Source.Hotpluggable
+		return volume, nil
+	case CloudInitNoCloud:
+		return generateCloudInitNoCloudVolume(vol.Name, vol.Source.Password, vol.Source.SSHAuthorizedKey), nil
+	case ContainerDisk:
+		source.ContainerDisk = &kubev1.ContainerDiskSource{
+			Image:           vol.Source.Image,
+			ImagePullPolicy: vol.Source.ImagePullPolicy,
+			ImagePullSecret: vol.Source.ImagePullSecret,
+		}
+	case PersistentVolumeClaim:
+		source.PersistentVolumeClaim = &kubev1.PersistentVolumeClaimVolumeSource{
+			PersistentVolumeClaimVolumeSource: corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: sourceName,
+				ReadOnly:  vol.Disk.ReadOnly,
+			},
+			Hotpluggable: vol.Source.Hotpluggable,
+		}
+	case Ephemeral:
+		source.Ephemeral = &kubev1.EphemeralVolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: sourceName,
+				ReadOnly:  vol.Disk.ReadOnly,
+			},
+		}
+	case EmptyDisk:
+		source.EmptyDisk = &kubev1.EmptyDiskSource{
+			Capacity: (*utils.GenerateResourceList("", "", vol.Source.Capacity, ""))[corev1.ResourceStorage],
+		}
+	case HostDisk:
+		source.HostDisk = &kubev1.HostDisk{
+			Path: vol.Source.Path,
+			Type: kubev1.HostDiskExists,
+		}
+		// Only create the disk image if a size for it was provided
+		if capacity, ok := (*utils.GenerateResourceList("", "", vol.Source.Capacity, ""))[corev1.ResourceStorage]; ok {
+			source.HostDisk.Type = kubev1.HostDiskExistsOrCreate
+			source.HostDisk.Capacity = capacity
+		}
+	case Secret:
+		source.Secret = &kubev1.SecretVolumeSource{
+			SecretName: sourceName,
+		}
+	case ConfigMap:
+		source.ConfigMap = &kubev1.ConfigMapVolumeSource{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: sourceName,
+			},
+		}
+	case ServiceAccount:
+		source.ServiceAccount = &kubev1.ServiceAccountVolumeSource{
+			ServiceAccountName: sourceName,
+		}
+	case DownwardMetrics:
+		source.DownwardMetrics = &kubev1.DownwardMetricsVolumeSource{}
+	default:
+		return kubev1.Volume{}, fmt.Errorf("volume %s has unsupported type %q", vol.Name, vol.Type)
+	}
+
+	return kubev1.Volume{
+		Name:         vol.Name,
+		VolumeSource: source,
+	}, nil
+}
+
+func generateVolume(name, dvName string) kubev1.Volume {
 	volume := kubev1.Volume{
 		Name: name,
 		VolumeSource: kubev1.VolumeSource{
 			DataVolume: &kubev1.DataVolumeSource{
-				Name: name,
+				Name: dvName,
 			},
 		},
 	}
@@ -185,12 +553,15 @@ func generateVolume(name string) kubev1.Volume {
 
 // This func allows only setting cloud-user password and SSH authorized keys
 // and not the full functionality of cloud-init
-func generateCloudInitNoCloudVolume(name string) kubev1.Volume {
+func generateCloudInitNoCloudVolume(name, password, sshKey string) kubev1.Volume {
+	if password == "" {
+		password = generateRandPassword(3)
+	}
 	volume := kubev1.Volume{
 		Name: name,
 		VolumeSource: kubev1.VolumeSource{
 			CloudInitNoCloud: &kubev1.CloudInitNoCloudSource{
-				UserData: genUserData(generateRandPassword(3), ""),
+				UserData: genUserData(password, sshKey),
 			},
 		},
 	}
@@ -208,27 +579,25 @@ ssh_authorized_keys:
 `, password, sshKey)
 }
 
-func generateNetwork(ns string, net Network) kubev1.Network {
+func generateNetwork(ns string, net Network) (kubev1.Network, error) {
 	network := kubev1.DefaultPodNetwork()
-	if net.Type == BridgeNetwork {
-		if net.NADName != nil {
-			network.NetworkSource = kubev1.NetworkSource{
-				Multus: &kubev1.MultusNetwork{
-					// Format <namespace>/<nad-name> or <nad-name>
-					NetworkName: fmt.Sprintf("%s/%s", ns, *net.NADName),
-				},
-			}
-		} else {
-			// If NADName is not provided but NetworkType "Bridge" is provided, network Name is assumed as the NAD name
-			network.NetworkSource = kubev1.NetworkSource{
-				Multus: &kubev1.MultusNetwork{
-					NetworkName: fmt.Sprintf("%s/%s", ns, net.Name),
-				},
-			}
+	if net.Type == BridgeNetwork || net.Type == SRIOVNetwork {
+		if net.NADName == nil {
+			return kubev1.Network{}, fmt.Errorf("network %s of type %s has no NetworkAttachmentDefinition", net.Name, net.Type)
+		}
+		nadNamespace := ns
+		if net.NADNamespace != "" {
+			nadNamespace = net.NADNamespace
+		}
+		network.NetworkSource = kubev1.NetworkSource{
+			Multus: &kubev1.MultusNetwork{
+				// Format <namespace>/<nad-name>
+				NetworkName: fmt.Sprintf("%s/%s", nadNamespace, *net.NADName),
+			},
 		}
 	}
 	network.Name = net.Name
-	return *network
+	return *network, nil
 }
 
 func generateRandPassword(parts int) string {
@@ -238,3 +607,54 @@ func generateRandPassword(parts int) string {
 	}
 	return strings.Join(pass, "-")
 }
+
+func boolOrDefault(b *bool, def bool) bool {
+	if b == nil {
+		return def
+	}
+	return *b
+}
+
+func generateInstancetypeMatcher(ref *InstancetypeRef) *kubev1.InstancetypeMatcher {
+	if ref == nil {
+		return nil
+	}
+
+	// The kind of an inferred instancetype is read from the volume's labels as well
+	if ref.InferFromVolume != "" {
+		return &kubev1.InstancetypeMatcher{
+			InferFromVolume: ref.InferFromVolume,
+		}
+	}
+
+	matcher := kubev1.InstancetypeMatcher{
+		Name: ref.Name,
+		Kind: ClusterInstancetypeKind,
+	}
+	if ref.Kind != "" {
+		matcher.Kind = ref.Kind
+	}
+	return &matcher
+}
+
+func generatePreferenceMatcher(ref *PreferenceRef) *kubev1.PreferenceMatcher {
+	if ref == nil {
+		return nil
+	}
+
+	// The kind of an inferred preference is read from the volume's labels as well
+	if ref.InferFromVolume != "" {
+		return &kubev1.PreferenceMatcher{
+			InferFromVolume: ref.InferFromVolume,
+		}
+	}
+
+	matcher := kubev1.PreferenceMatcher{
+		Name: ref.Name,
+		Kind: ClusterPreferenceKind,
+	}
+	if ref.Kind != "" {
+		matcher.Kind = ref.Kind
+	}
+	return &matcher
+}